@@ -0,0 +1,343 @@
+package fsx
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumAlgo is a hash algorithm supported by checksum files.
+type ChecksumAlgo string
+
+const (
+	ChecksumMD5    ChecksumAlgo = "md5"
+	ChecksumSHA256 ChecksumAlgo = "sha256"
+)
+
+// ChecksumStatus is the verify result of a single entry.
+type ChecksumStatus string
+
+const (
+	ChecksumOK      ChecksumStatus = "OK"
+	ChecksumFailed  ChecksumStatus = "FAILED"
+	ChecksumMissing ChecksumStatus = "MISSING"
+)
+
+// ChecksumEntry is one line of a checksum file.
+type ChecksumEntry struct {
+	Algo ChecksumAlgo
+	Sum  string
+	Name string
+}
+
+// ChecksumResult is the verify result of a ChecksumEntry.
+type ChecksumResult struct {
+	ChecksumEntry
+	Status ChecksumStatus
+	Actual string
+	Err    error
+}
+
+// ChecksumReport collects the results of VerifyChecksums.
+type ChecksumReport struct {
+	Results []ChecksumResult
+}
+
+// Checksum returns the hex digest of the file with the given algorithm.
+func (fs *FS) Checksum(algo ChecksumAlgo) (string, error) {
+	return fs.ChecksumContext(context.Background(), algo, nil)
+}
+
+// ChecksumContext is Checksum with cancellation and progress events.
+func (fs *FS) ChecksumContext(ctx context.Context, algo ChecksumAlgo, progress ProgressFunc) (string, error) {
+	h, err := algo.newHash()
+	if err != nil {
+		return "", err
+	}
+	return fs.hashContext(ctx, string(algo), h, progress)
+}
+
+func (algo ChecksumAlgo) newHash() (hash.Hash, error) {
+	switch algo {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("checksum algorithm %q: %w", algo, ErrUnsupported)
+}
+
+// WriteChecksums writes a sha256sum/md5sum compatible checksum file to fs.
+// Names are written relative to the directory of the checksum file.
+func (fs *FS) WriteChecksums(algo ChecksumAlgo, files ...*FS) error {
+	entries := make([]ChecksumEntry, 0, len(files))
+	for _, file := range files {
+		sum, err := file.Checksum(algo)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(filepath.Dir(fs.path), file.path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, ChecksumEntry{Algo: algo, Sum: sum, Name: filepath.ToSlash(name)})
+	}
+
+	f, err := os.Create(fs.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := FormatChecksums(f, entries); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// FormatChecksums writes entries in GNU coreutils format, "<sum>  <name>".
+func FormatChecksums(w io.Writer, entries []ChecksumEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		name, prefix := e.Name, ""
+		// coreutils escapes names with backslash or newline and marks the line with a leading backslash
+		if strings.ContainsAny(name, "\\\n") {
+			name = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+			prefix = "\\"
+		}
+		if _, err := fmt.Fprintf(bw, "%s%s  %s\n", prefix, e.Sum, name); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ParseChecksums parses GNU ("<sum>  <name>", "<sum> *<name>") and BSD ("SHA256 (<name>) = <sum>") checksum lines.
+// Blank lines and lines starting with '#' are skipped.
+func ParseChecksums(r io.Reader) ([]ChecksumEntry, error) {
+	var entries []ChecksumEntry
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+
+		entry, ok := parseBSDChecksum(line)
+		if !ok {
+			entry, ok = parseGNUChecksum(line)
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid checksum line %d: %q", n, line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func parseBSDChecksum(line string) (ChecksumEntry, bool) {
+	open := strings.Index(line, " (")
+	closing := strings.LastIndex(line, ") = ")
+	if open <= 0 || closing < open {
+		return ChecksumEntry{}, false
+	}
+
+	algo := checksumAlgoByName(line[:open])
+	sum := strings.ToLower(line[closing+4:])
+	if algo == "" || !isChecksumHex(sum, algo) {
+		return ChecksumEntry{}, false
+	}
+	return ChecksumEntry{Algo: algo, Sum: sum, Name: line[open+2 : closing]}, true
+}
+
+func parseGNUChecksum(line string) (ChecksumEntry, bool) {
+	escaped := line[0] == '\\'
+	if escaped {
+		line = line[1:]
+	}
+
+	idx := strings.IndexByte(line, ' ')
+	if idx <= 0 || idx+2 > len(line) {
+		return ChecksumEntry{}, false
+	}
+
+	sum := strings.ToLower(line[:idx])
+	algo := checksumAlgoByLen(len(sum))
+	if algo == "" || !isChecksumHex(sum, algo) {
+		return ChecksumEntry{}, false
+	}
+
+	// the second separator char is ' ' for text mode and '*' for binary mode
+	if line[idx+1] != ' ' && line[idx+1] != '*' {
+		return ChecksumEntry{}, false
+	}
+	name := line[idx+2:]
+	if escaped {
+		name = unescapeChecksumName(name)
+	}
+	return ChecksumEntry{Algo: algo, Sum: sum, Name: name}, name != ""
+}
+
+func unescapeChecksumName(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func checksumAlgoByName(name string) ChecksumAlgo {
+	switch strings.ToUpper(name) {
+	case "MD5":
+		return ChecksumMD5
+	case "SHA256":
+		return ChecksumSHA256
+	}
+	return ""
+}
+
+func checksumAlgoByLen(n int) ChecksumAlgo {
+	switch n {
+	case 32:
+		return ChecksumMD5
+	case 64:
+		return ChecksumSHA256
+	}
+	return ""
+}
+
+func isChecksumHex(sum string, algo ChecksumAlgo) bool {
+	if checksumAlgoByLen(len(sum)) != algo {
+		return false
+	}
+	for i := 0; i < len(sum); i++ {
+		c := sum[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyChecksums verifies every entry of the checksum file fs, relative names are resolved
+// against the directory of the checksum file.
+func (fs *FS) VerifyChecksums() (*ChecksumReport, error) {
+	return fs.VerifyChecksumsContext(context.Background(), nil)
+}
+
+// VerifyChecksumsContext is VerifyChecksums with cancellation and progress events over all files.
+func (fs *FS) VerifyChecksumsContext(ctx context.Context, progress ProgressFunc) (*ChecksumReport, error) {
+	f, err := fs.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := ParseChecksums(f)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(fs.path)
+	var total int64
+	for _, entry := range entries {
+		if info, err := os.Stat(checksumPath(dir, entry)); err == nil {
+			total += info.Size()
+		}
+	}
+	t := newTracker(ctx, "verify", total, progress)
+
+	report := &ChecksumReport{Results: make([]ChecksumResult, 0, len(entries))}
+	for _, entry := range entries {
+		result := verifyChecksum(dir, entry, t)
+		// a cancelled hash is not a failed entry
+		if err := t.err(); err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, result)
+	}
+	t.finish()
+	return report, nil
+}
+
+func checksumPath(dir string, entry ChecksumEntry) string {
+	name := filepath.FromSlash(entry.Name)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	return name
+}
+
+func verifyChecksum(dir string, entry ChecksumEntry, t *tracker) ChecksumResult {
+	result := ChecksumResult{ChecksumEntry: entry}
+
+	file, err := New(checksumPath(dir, entry))
+	if err != nil {
+		result.Status, result.Err = ChecksumMissing, err
+		return result
+	}
+	if !file.Exists() {
+		result.Status = ChecksumMissing
+		return result
+	}
+
+	h, err := entry.Algo.newHash()
+	if err == nil {
+		result.Actual, err = file.hashTracked(h, t)
+	}
+	result.Err = wrapErr(string(entry.Algo), file.path, "", err)
+	if result.Err == nil && result.Actual == entry.Sum {
+		result.Status = ChecksumOK
+	} else {
+		result.Status = ChecksumFailed
+	}
+	return result
+}
+
+// OK reports whether every entry verified.
+func (r *ChecksumReport) OK() bool {
+	return r.Count(ChecksumOK) == len(r.Results)
+}
+
+// Count returns the number of results with the given status.
+func (r *ChecksumReport) Count(status ChecksumStatus) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode returns 0 when every entry verified and 1 otherwise, like sha256sum -c.
+func (r *ChecksumReport) ExitCode() int {
+	if r.OK() {
+		return 0
+	}
+	return 1
+}
+
+// String formats the report like sha256sum -c, "<name>: OK".
+func (r *ChecksumReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "%s: %s\n", result.Name, result.Status)
+	}
+	return b.String()
+}
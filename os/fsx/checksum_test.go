@@ -0,0 +1,67 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChecksums(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		"d41d8cd98f00b204e9800998ecf8427e  empty.txt",
+		"E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855 *bin/app",
+		"SHA256 (dist/app.tar.gz) = e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"\\d41d8cd98f00b204e9800998ecf8427e  a\\\\b\\nc",
+		"",
+	}, "\n")
+
+	entries, err := ParseChecksums(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []ChecksumEntry{
+		{Algo: ChecksumMD5, Sum: "d41d8cd98f00b204e9800998ecf8427e", Name: "empty.txt"},
+		{Algo: ChecksumSHA256, Sum: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Name: "bin/app"},
+		{Algo: ChecksumSHA256, Sum: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Name: "dist/app.tar.gz"},
+		{Algo: ChecksumMD5, Sum: "d41d8cd98f00b204e9800998ecf8427e", Name: "a\\b\nc"},
+	}, entries)
+
+	_, err = ParseChecksums(strings.NewReader("not a checksum line\n"))
+	assert.Error(t, err)
+}
+
+func TestFS_VerifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	var files []*FS
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		f, err := New(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		files = append(files, f)
+	}
+
+	sums, err := New(filepath.Join(dir, "SHA256SUMS"))
+	assert.NoError(t, err)
+	assert.NoError(t, sums.WriteChecksums(ChecksumSHA256, files...))
+
+	report, err := sums.VerifyChecksums()
+	assert.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 0, report.ExitCode())
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("changed"), 0o644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "c.txt")))
+
+	report, err = sums.VerifyChecksums()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Count(ChecksumOK))
+	assert.Equal(t, 1, report.Count(ChecksumFailed))
+	assert.Equal(t, 1, report.Count(ChecksumMissing))
+	assert.Equal(t, 1, report.ExitCode())
+	assert.Equal(t, "a.txt: OK\nb.txt: FAILED\nc.txt: MISSING\n", report.String())
+}
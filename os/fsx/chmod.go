@@ -0,0 +1,434 @@
+package fsx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// unix permission bits used by chmod(1)
+const (
+	modeSetuid uint32 = 0o4000
+	modeSetgid uint32 = 0o2000
+	modeSticky uint32 = 0o1000
+	modeRead   uint32 = 0o444
+	modeWrite  uint32 = 0o222
+	modeExec   uint32 = 0o111
+	modeAll    uint32 = 0o7777
+
+	modeWhoUser  = modeSetuid | 0o700
+	modeWhoGroup = modeSetgid | 0o070
+	modeWhoOther = modeSticky | 0o007
+)
+
+const (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+type modeOp struct {
+	op    byte   // one of '+', '-', '='
+	perm  uint32 // bits from [rwxst]
+	copy  byte   // copy bits from 'u', 'g' or 'o'
+	condX bool   // X: execute only for directories or already executable files
+}
+
+type modeClause struct {
+	who uint32 // affected bits, 0 when no [ugoa] given
+	ops []modeOp
+}
+
+// SymbolicMode is a parsed chmod(1) mode expression such as "u+rwX,g-w,o=" or "0755".
+type SymbolicMode struct {
+	expr    string
+	octal   bool
+	value   uint32
+	digits  int
+	clauses []modeClause
+}
+
+// ParseSymbolicMode parses a chmod(1) mode expression, symbolic or octal.
+func ParseSymbolicMode(expr string) (*SymbolicMode, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("invalid mode: %q", expr)
+	}
+
+	if expr[0] >= '0' && expr[0] <= '7' {
+		v, err := strconv.ParseUint(expr, 8, 32)
+		if err != nil || uint32(v) > modeAll {
+			return nil, fmt.Errorf("invalid mode: %q", expr)
+		}
+		return &SymbolicMode{expr: expr, octal: true, value: uint32(v), digits: len(expr)}, nil
+	}
+
+	m := &SymbolicMode{expr: expr}
+	for _, part := range strings.Split(expr, ",") {
+		clause, err := parseModeClause(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode: %q", expr)
+		}
+		m.clauses = append(m.clauses, clause)
+	}
+	return m, nil
+}
+
+func parseModeClause(s string) (modeClause, error) {
+	var c modeClause
+	i := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case 'u':
+			c.who |= modeWhoUser
+		case 'g':
+			c.who |= modeWhoGroup
+		case 'o':
+			c.who |= modeWhoOther
+		case 'a':
+			c.who |= modeAll
+		default:
+			goto ops
+		}
+	}
+ops:
+	if i == len(s) {
+		return c, errors.New("missing operator")
+	}
+
+	for i < len(s) {
+		op := modeOp{op: s[i]}
+		if op.op != '+' && op.op != '-' && op.op != '=' {
+			return c, errors.New("invalid operator")
+		}
+		i++
+
+		if i < len(s) && (s[i] == 'u' || s[i] == 'g' || s[i] == 'o') {
+			op.copy = s[i]
+			i++
+		} else {
+			for ; i < len(s); i++ {
+				switch s[i] {
+				case 'r':
+					op.perm |= modeRead
+				case 'w':
+					op.perm |= modeWrite
+				case 'x':
+					op.perm |= modeExec
+				case 'X':
+					op.condX = true
+				case 's':
+					op.perm |= modeSetuid | modeSetgid
+				case 't':
+					op.perm |= modeSticky
+				default:
+					goto next
+				}
+			}
+		}
+	next:
+		c.ops = append(c.ops, op)
+	}
+	return c, nil
+}
+
+// String returns the original expression.
+func (m *SymbolicMode) String() string {
+	return m.expr
+}
+
+// Apply returns mode with the expression applied, isDir controls X and setuid/setgid handling.
+func (m *SymbolicMode) Apply(mode os.FileMode, isDir bool) os.FileMode {
+	bits := toUnixMode(mode)
+
+	if m.octal {
+		keep := uint32(0)
+		// like chmod(1), short octal modes keep setuid/setgid on directories
+		if isDir && m.digits < 5 {
+			keep = bits & (modeSetuid | modeSetgid)
+		}
+		return fromUnixMode(m.value|keep, mode)
+	}
+
+	mask := umask()
+	for _, c := range m.clauses {
+		affected := c.who
+		if affected == 0 {
+			affected = modeAll
+		}
+
+		for _, op := range c.ops {
+			value := op.perm
+			switch {
+			case op.copy != 0:
+				value = copyModeBits(bits, op.copy)
+			case op.condX:
+				if isDir || bits&modeExec != 0 {
+					value |= modeExec
+				}
+			}
+
+			value &= affected
+			if c.who == 0 {
+				value &^= mask
+			}
+
+			switch op.op {
+			case '+':
+				bits |= value
+			case '-':
+				bits &^= value
+			case '=':
+				preserved := ^affected
+				if isDir && op.perm&(modeSetuid|modeSetgid) == 0 {
+					preserved |= modeSetuid | modeSetgid
+				}
+				bits = (bits & preserved) | value
+			}
+		}
+	}
+	return fromUnixMode(bits&modeAll, mode)
+}
+
+// replicate rwx of the given class to all classes
+func copyModeBits(bits uint32, from byte) uint32 {
+	var rwx uint32
+	switch from {
+	case 'u':
+		rwx = (bits >> 6) & 0o7
+	case 'g':
+		rwx = (bits >> 3) & 0o7
+	case 'o':
+		rwx = bits & 0o7
+	}
+	return rwx * 0o111
+}
+
+func toUnixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= modeSetuid
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= modeSetgid
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= modeSticky
+	}
+	return bits
+}
+
+// fromUnixMode converts bits back to a FileMode, keeping the type bits of orig.
+func fromUnixMode(bits uint32, orig os.FileMode) os.FileMode {
+	mode := orig.Type() | os.FileMode(bits&0o777)
+	if bits&modeSetuid != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&modeSetgid != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&modeSticky != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// FormatMode formats mode the way ls -l does, e.g. "drwxr-x---".
+func FormatMode(mode fs.FileMode) string {
+	buf := []byte("----------")
+
+	switch {
+	case mode&fs.ModeDir != 0:
+		buf[0] = 'd'
+	case mode&fs.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&fs.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	case mode&fs.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&fs.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		buf[0] = 'b'
+	}
+
+	const rwx = "rwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i%3]
+		}
+	}
+
+	special := func(idx int, set bool, exec, noExec byte) {
+		if !set {
+			return
+		}
+		if buf[idx] == 'x' {
+			buf[idx] = exec
+		} else {
+			buf[idx] = noExec
+		}
+	}
+	special(3, mode&fs.ModeSetuid != 0, 's', 'S')
+	special(6, mode&fs.ModeSetgid != 0, 's', 'S')
+	special(9, mode&fs.ModeSticky != 0, 't', 'T')
+
+	return string(buf)
+}
+
+// ModeString returns the ls style mode of the path, symlinks are not followed.
+func (fs *FS) ModeString() (string, error) {
+	info, err := os.Lstat(fs.path)
+	if err != nil {
+		return "", wrapErr("mode", fs.path, "", err)
+	}
+	return FormatMode(info.Mode()), nil
+}
+
+// ChmodSymbolic changes permission with a chmod(1) expression, e.g. "u+rwX,g-w,o=".
+func (fs *FS) ChmodSymbolic(expr string) (err error) {
+	defer func() { err = wrapErr("chmod", fs.path, "", err) }()
+
+	mode, err := ParseSymbolicMode(expr)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return err
+	}
+	fs.fileInfo = nil
+	return os.Chmod(fs.path, mode.Apply(info.Mode(), info.IsDir()))
+}
+
+// ChmodRecursive changes permission of every file to fileMode and every directory to dirMode,
+// symlinks are skipped.
+func (fs *FS) ChmodRecursive(fileMode, dirMode os.FileMode) error {
+	fs.fileInfo = nil
+	return wrapErr("chmod", fs.path, "", filepath.WalkDir(fs.path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Chmod(path, dirMode)
+		case d.Type()&os.ModeSymlink != 0:
+			return nil
+		default:
+			return os.Chmod(path, fileMode)
+		}
+	}))
+}
+
+// ChmodSymbolicRecursive applies a chmod(1) expression to the whole tree, symlinks are skipped.
+func (fs *FS) ChmodSymbolicRecursive(expr string) (err error) {
+	defer func() { err = wrapErr("chmod", fs.path, "", err) }()
+
+	mode, err := ParseSymbolicMode(expr)
+	if err != nil {
+		return err
+	}
+
+	fs.fileInfo = nil
+	return filepath.WalkDir(fs.path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.Chmod(path, mode.Apply(info.Mode(), d.IsDir()))
+	})
+}
+
+// ChownName changes owner and group by name or numeric id, an empty name leaves it unchanged.
+func (fs *FS) ChownName(owner, group string) error {
+	uid, gid, err := lookupOwner(owner, group)
+	if err != nil {
+		return wrapErr("chown", fs.path, "", err)
+	}
+	return wrapErr("chown", fs.path, "", os.Chown(fs.path, uid, gid))
+}
+
+// ChownRecursive changes owner and group of the whole tree, symlinks themselves are changed,
+// not their targets. Pass -1 to leave an id unchanged.
+func (fs *FS) ChownRecursive(uid, gid int) error {
+	return wrapErr("chown", fs.path, "", filepath.WalkDir(fs.path, func(path string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	}))
+}
+
+// ChownNameRecursive is ChownRecursive with user and group names.
+func (fs *FS) ChownNameRecursive(owner, group string) error {
+	uid, gid, err := lookupOwner(owner, group)
+	if err != nil {
+		return wrapErr("chown", fs.path, "", err)
+	}
+	return fs.ChownRecursive(uid, gid)
+}
+
+func lookupOwner(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner != "" {
+		if uid, err = LookupUid(owner); err != nil {
+			return
+		}
+	}
+	if group != "" {
+		if gid, err = LookupGid(group); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// LookupUid resolves a user name from /etc/passwd, numeric names are returned as is.
+func LookupUid(name string) (int, error) {
+	return lookupID(passwdFile, name)
+}
+
+// LookupGid resolves a group name from /etc/group, numeric names are returned as is.
+func LookupGid(name string) (int, error) {
+	return lookupID(groupFile, name)
+}
+
+// both passwd and group files keep the name in field 0 and the id in field 2
+func lookupID(file, name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+		return strconv.Atoi(fields[2])
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, err
+	}
+	return -1, fmt.Errorf("%s: unknown name %q", file, name)
+}
@@ -0,0 +1,91 @@
+package fsx
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolicMode_Apply(t *testing.T) {
+	tests := []struct {
+		expr  string
+		mode  fs.FileMode
+		isDir bool
+		want  fs.FileMode
+	}{
+		{"u+rwX,g-w,o=", 0o664, false, 0o640},
+		{"u+rwX,g-w,o=", 0o644, true, 0o740},
+		{"a+X", 0o644, false, 0o644},
+		{"a+X", 0o744, false, 0o755},
+		{"go=u", 0o750, false, 0o777},
+		{"u=rw,go=r", 0o777, false, 0o644},
+		{"u+s,g+s", 0o755, false, 0o755 | fs.ModeSetuid | fs.ModeSetgid},
+		{"+t", 0o777, true, 0o777 | fs.ModeSticky},
+		{"o+t", 0o777, true, 0o777 | fs.ModeSticky},
+		{"u-x+s", 0o755, false, 0o655 | fs.ModeSetuid},
+		{"0750", 0o777 | fs.ModeSetgid, true, 0o750 | fs.ModeSetgid},
+		{"00750", 0o777 | fs.ModeSetgid, true, 0o750},
+		{"4755", 0o644, false, 0o755 | fs.ModeSetuid},
+	}
+	for _, tt := range tests {
+		m, err := ParseSymbolicMode(tt.expr)
+		if !assert.NoError(t, err, tt.expr) {
+			continue
+		}
+		mode := tt.mode
+		if tt.isDir {
+			mode |= fs.ModeDir
+		}
+		got := m.Apply(mode, tt.isDir)
+		assert.Equal(t, FormatMode(tt.want|mode.Type()), FormatMode(got), tt.expr)
+	}
+
+	for _, expr := range []string{"", "u", "u+q", "z+r", "8755", "u+r,"} {
+		_, err := ParseSymbolicMode(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFormatMode(t *testing.T) {
+	assert.Equal(t, "drwxr-x---", FormatMode(fs.ModeDir|0o750))
+	assert.Equal(t, "-rwsr-Sr-T", FormatMode(0o744|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky))
+	assert.Equal(t, "lrwxrwxrwx", FormatMode(fs.ModeSymlink|0o777))
+	assert.Equal(t, "crw-rw-rw-", FormatMode(fs.ModeDevice|fs.ModeCharDevice|0o666))
+}
+
+func TestFS_ChmodRecursive(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0o777))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "f"), nil, 0o666))
+
+	fs, err := New(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, fs.ChmodRecursive(0o600, 0o700))
+
+	info, err := os.Stat(filepath.Join(dir, "a", "b", "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-rw-------", FormatMode(info.Mode()))
+
+	info, err = os.Stat(filepath.Join(dir, "a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "drwx------", FormatMode(info.Mode()))
+}
+
+func TestLookupID(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passwd")
+	assert.NoError(t, os.WriteFile(file, []byte("# comment\nroot:x:0:0:root:/root:/bin/sh\ndeploy:x:1001:1001::/home/deploy:/bin/sh\n"), 0o644))
+
+	id, err := lookupID(file, "deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, 1001, id)
+
+	id, err = lookupID(file, "42")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = lookupID(file, "nobody")
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package fsx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// CleanupOrder selects which files go first when trimming to a size budget.
+type CleanupOrder int
+
+const (
+	OldestFirst CleanupOrder = iota
+	LargestFirst
+)
+
+// CleanupPolicy selects files to remove from a directory. The rules run in field order on the files
+// matching Pattern, a rule left at its zero value is disabled.
+type CleanupPolicy struct {
+	// Pattern limits the policy to matching files, see the include globs of Replace.
+	Pattern string
+	// Recursive includes files in subdirectories.
+	Recursive bool
+
+	// OlderThan removes files last modified longer ago than that.
+	OlderThan time.Duration
+	// KeepNewest removes all but the newest n files.
+	KeepNewest int
+	// MaxTotal removes files in Order until the rest takes at most that much space.
+	MaxTotal units.Base2Bytes
+	Order    CleanupOrder
+
+	// DryRun only reports what would be removed.
+	DryRun bool
+	// TrashDir moves files there, keeping their relative path, instead of deleting them.
+	TrashDir string
+	// Now is the reference time of OlderThan, defaults to time.Now().
+	Now time.Time
+}
+
+// CleanupFile is a file removed by Cleanup, Path is relative to the directory.
+type CleanupFile struct {
+	Path    string
+	Size    units.Base2Bytes
+	ModTime time.Time
+	Reason  string
+}
+
+// CleanupReport is the result of Cleanup.
+type CleanupReport struct {
+	Removed []CleanupFile
+	Freed   units.Base2Bytes
+	DryRun  bool
+}
+
+// Cleanup applies the policy to the directory, removing or trashing the selected files.
+func (fs *FS) Cleanup(policy CleanupPolicy) (*CleanupReport, error) {
+	if policy.KeepNewest < 0 || policy.MaxTotal < 0 || policy.OlderThan < 0 {
+		return nil, wrapErr("cleanup", fs.path, "", ErrInvalid)
+	}
+	if policy.Now.IsZero() {
+		policy.Now = time.Now()
+	}
+
+	files, err := fs.cleanupCandidates(policy)
+	if err != nil {
+		return nil, err
+	}
+	// newest first, ties broken by name to keep the order stable
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].ModTime.After(files[j].ModTime)
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	var remove, keep []CleanupFile
+	for _, f := range files {
+		if policy.OlderThan > 0 && policy.Now.Sub(f.ModTime) > policy.OlderThan {
+			f.Reason = "older than " + policy.OlderThan.String()
+			remove = append(remove, f)
+		} else {
+			keep = append(keep, f)
+		}
+	}
+
+	if policy.KeepNewest > 0 && len(keep) > policy.KeepNewest {
+		for _, f := range keep[policy.KeepNewest:] {
+			f.Reason = fmt.Sprintf("not in newest %d", policy.KeepNewest)
+			remove = append(remove, f)
+		}
+		keep = keep[:policy.KeepNewest]
+	}
+
+	if policy.MaxTotal > 0 {
+		var total units.Base2Bytes
+		for _, f := range keep {
+			total += f.Size
+		}
+		if policy.Order == LargestFirst {
+			sort.SliceStable(keep, func(i, j int) bool { return keep[i].Size > keep[j].Size })
+		} else {
+			sort.SliceStable(keep, func(i, j int) bool { return keep[i].ModTime.Before(keep[j].ModTime) })
+		}
+		for len(keep) > 0 && total > policy.MaxTotal {
+			f := keep[0]
+			f.Reason = "over budget " + policy.MaxTotal.String()
+			remove = append(remove, f)
+			total -= f.Size
+			keep = keep[1:]
+		}
+	}
+
+	report := &CleanupReport{DryRun: policy.DryRun}
+	for _, f := range remove {
+		if !policy.DryRun {
+			if err := fs.cleanupFile(f.Path, policy); err != nil {
+				return report, err
+			}
+		}
+		report.Removed = append(report.Removed, f)
+		report.Freed += f.Size
+	}
+	return report, nil
+}
+
+func (fs *FS) cleanupCandidates(policy CleanupPolicy) ([]CleanupFile, error) {
+	trash := ""
+	if policy.TrashDir != "" {
+		trash, _ = filepath.Abs(policy.TrashDir)
+	}
+
+	var files []CleanupFile
+	err := filepath.Walk(fs.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != fs.path && (!policy.Recursive || path == trash) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(fs.path, path)
+		if err != nil {
+			return err
+		}
+		if policy.Pattern != "" && !matchGlob(policy.Pattern, filepath.ToSlash(rel)) {
+			return nil
+		}
+		files = append(files, CleanupFile{Path: rel, Size: units.Base2Bytes(info.Size()), ModTime: info.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+func (fs *FS) cleanupFile(rel string, policy CleanupPolicy) error {
+	path := filepath.Join(fs.path, rel)
+	if policy.TrashDir == "" {
+		return os.Remove(path)
+	}
+
+	target := filepath.Join(policy.TrashDir, rel)
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		target += "." + policy.Now.Format("20060102-150405.000000000")
+	}
+	if err := os.Rename(path, target); err == nil {
+		return nil
+	}
+
+	// the trash may live on another filesystem
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := copyFile(path, target, info); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
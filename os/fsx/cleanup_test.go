@@ -0,0 +1,68 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rogeecn/tl/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Cleanup(t *testing.T) {
+	now := time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC)
+	setup := func() (*FS, string) {
+		dir := t.TempDir()
+		for i, size := range []int{100, 400, 200, 300, 500} {
+			path := filepath.Join(dir, "build-"+string(rune('a'+i))+".tar")
+			assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644))
+			mtime := now.Add(-time.Duration(5-i) * 24 * time.Hour)
+			assert.NoError(t, os.Chtimes(path, mtime, mtime))
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0o644))
+		fs, err := New(dir)
+		assert.NoError(t, err)
+		return fs, dir
+	}
+	removed := func(r *CleanupReport) []string {
+		var names []string
+		for _, f := range r.Removed {
+			names = append(names, f.Path)
+		}
+		return names
+	}
+
+	fs, dir := setup()
+	report, err := fs.Cleanup(CleanupPolicy{Pattern: "*.tar", OlderThan: 72 * time.Hour, Now: now, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build-b.tar", "build-a.tar"}, removed(report))
+	assert.Equal(t, units.Base2Bytes(500), report.Freed)
+	_, err = os.Stat(filepath.Join(dir, "build-a.tar"))
+	assert.NoError(t, err, "dry run must not remove")
+
+	report, err = fs.Cleanup(CleanupPolicy{Pattern: "*.tar", KeepNewest: 2, Now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build-c.tar", "build-b.tar", "build-a.tar"}, removed(report))
+	_, err = os.Stat(filepath.Join(dir, "build-a.tar"))
+	assert.True(t, os.IsNotExist(err))
+
+	fs, _ = setup()
+	report, err = fs.Cleanup(CleanupPolicy{Pattern: "*.tar", MaxTotal: 1000, Order: LargestFirst, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build-e.tar"}, removed(report))
+
+	report, err = fs.Cleanup(CleanupPolicy{Pattern: "*.tar", MaxTotal: 1000, Order: OldestFirst, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build-a.tar", "build-b.tar"}, removed(report))
+
+	fs, dir = setup()
+	trash := filepath.Join(t.TempDir(), "trash")
+	_, err = fs.Cleanup(CleanupPolicy{Pattern: "build-a.tar", OlderThan: time.Hour, Now: now, TrashDir: trash})
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(trash, "build-a.tar"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "build-a.tar"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,199 @@
+package fsx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// CloneStrategy is how CloneTree materialised a file.
+type CloneStrategy int
+
+const (
+	// CloneReflink shares the data blocks copy-on-write (FICLONE on btrfs, xfs and others).
+	CloneReflink CloneStrategy = iota
+	// CloneHardlink links the file, source and clone are the same inode.
+	CloneHardlink
+	// CloneCopy copies the content.
+	CloneCopy
+)
+
+func (s CloneStrategy) String() string {
+	switch s {
+	case CloneReflink:
+		return "reflink"
+	case CloneHardlink:
+		return "hardlink"
+	case CloneCopy:
+		return "copy"
+	}
+	return "unknown"
+}
+
+// CloneOptions configures CloneTree.
+type CloneOptions struct {
+	// AllowHardlink links files that cannot be reflinked. Writes to a linked file change the source
+	// too, only allow it when neither tree is modified in place.
+	AllowHardlink bool
+	Progress      ProgressFunc
+}
+
+// CloneFile is a file cloned by CloneTree, Path is relative to the tree.
+type CloneFile struct {
+	Path     string
+	Size     units.Base2Bytes
+	Strategy CloneStrategy
+}
+
+// CloneReport is the result of CloneTree.
+type CloneReport struct {
+	Files []CloneFile
+}
+
+// Count returns the number of files cloned with the strategy.
+func (r *CloneReport) Count(strategy CloneStrategy) int {
+	n := 0
+	for _, f := range r.Files {
+		if f.Strategy == strategy {
+			n++
+		}
+	}
+	return n
+}
+
+// CloneTree recreates the directory at dst, which must not exist and is removed again on failure. Every file is reflinked where the
+// filesystem supports it, then hard-linked if allowed, and copied otherwise. Directories, symlinks,
+// modes and mtimes are recreated, sockets, pipes and devices are skipped.
+func (fs *FS) CloneTree(ctx context.Context, dst string, opts CloneOptions) (_ *CloneReport, err error) {
+	defer func() { err = wrapErr("clone", fs.path, dst, err) }()
+
+	if !fs.IsDir() {
+		return nil, ErrNotDir
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return nil, ErrExists
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dst)
+		}
+	}()
+
+	total, err := treeSize(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	t := newTracker(ctx, "clone", total, opts.Progress)
+
+	type dirInfo struct {
+		path string
+		info os.FileInfo
+	}
+	var dirs []dirInfo
+	// devices of the source where reflinks are not supported, trees may span mounts
+	noReflink := map[uint64]bool{}
+	report := &CloneReport{}
+
+	err = filepath.Walk(fs.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := t.err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(fs.path, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			dirs = append(dirs, dirInfo{target, info})
+			return os.MkdirAll(target, 0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		t.file(rel)
+		f := CloneFile{Path: rel, Size: units.Base2Bytes(info.Size()), Strategy: CloneReflink}
+		dev := deviceID(info)
+		reflinked := false
+		if !noReflink[dev] {
+			err := reflinkFile(path, target, info)
+			if reflinkUnsupported(err) {
+				noReflink[dev] = true
+			}
+			reflinked = err == nil
+		}
+		if !reflinked {
+			f.Strategy = CloneCopy
+			if opts.AllowHardlink && os.Link(path, target) == nil {
+				f.Strategy = CloneHardlink
+			} else if err := copyFile(path, target, info); err != nil {
+				return err
+			}
+		}
+		t.add(info.Size())
+		report.Files = append(report.Files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		if err := os.Chtimes(dirs[i].path, dirs[i].info.ModTime(), dirs[i].info.ModTime()); err != nil {
+			return nil, err
+		}
+	}
+	t.finish()
+	return report, nil
+}
+
+// reflinkUnsupported reports whether err means the filesystem cannot reflink at all, as opposed to
+// failing for a single file.
+func reflinkUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported) || errors.Is(err, syscall.EOPNOTSUPP) ||
+		errors.Is(err, syscall.EXDEV) || errors.Is(err, syscall.EINVAL)
+}
+
+// reflinkFile clones src to the new file dst with the mode and mtime of info, dst is removed again
+// when the filesystem cannot reflink.
+func reflinkFile(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err := reflink(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
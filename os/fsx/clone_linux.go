@@ -0,0 +1,21 @@
+package fsx
+
+import (
+	"os"
+	"syscall"
+)
+
+func reflink(dst, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "ioctl FICLONE", Err: errno}
+	}
+	return nil
+}
+
+func deviceID(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev)
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package fsx
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func reflink(dst, src *os.File) error {
+	return fmt.Errorf("reflink on %s: %w", runtime.GOOS, ErrUnsupported)
+}
+
+func deviceID(info os.FileInfo) uint64 {
+	return 0
+}
@@ -0,0 +1,78 @@
+package fsx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_CloneTree(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cache")
+	writeTestTree(t, src, map[string]string{
+		"a.txt":         "alpha\n",
+		"pkg/mod/b.txt": "beta\n",
+	})
+	assert.NoError(t, os.Chmod(filepath.Join(src, "a.txt"), 0o600))
+	assert.NoError(t, os.Symlink("a.txt", filepath.Join(src, "link")))
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+
+	dst := filepath.Join(dir, "copy")
+	report, err := fs.CloneTree(context.Background(), dst, CloneOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, report.Files, 2)
+	assert.Zero(t, report.Count(CloneHardlink))
+	assert.Equal(t, 2, report.Count(CloneReflink)+report.Count(CloneCopy))
+
+	data, err := os.ReadFile(filepath.Join(dst, "pkg/mod/b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "beta\n", string(data))
+	info, err := os.Stat(filepath.Join(dst, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt", link)
+
+	// the clone is independent of the source
+	assert.NoError(t, os.WriteFile(filepath.Join(dst, "a.txt"), []byte("changed\n"), 0o600))
+	data, err = os.ReadFile(filepath.Join(src, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha\n", string(data))
+
+	_, err = fs.CloneTree(context.Background(), dst, CloneOptions{})
+	assert.ErrorIs(t, err, ErrExists)
+
+	linked := filepath.Join(dir, "linked")
+	report, err = fs.CloneTree(context.Background(), linked, CloneOptions{AllowHardlink: true})
+	assert.NoError(t, err)
+	assert.Zero(t, report.Count(CloneCopy))
+	for _, f := range report.Files {
+		if f.Strategy != CloneHardlink {
+			continue
+		}
+		a, _ := os.Stat(filepath.Join(src, f.Path))
+		b, _ := os.Stat(filepath.Join(linked, f.Path))
+		assert.True(t, os.SameFile(a, b), f.Path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fs.CloneTree(ctx, filepath.Join(dir, "cancelled"), CloneOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoDirExists(t, filepath.Join(dir, "cancelled"))
+}
+
+func TestReflinkUnsupported(t *testing.T) {
+	assert.True(t, reflinkUnsupported(&os.SyscallError{Syscall: "ioctl FICLONE", Err: syscall.EXDEV}))
+	assert.True(t, reflinkUnsupported(&os.SyscallError{Syscall: "ioctl FICLONE", Err: syscall.EOPNOTSUPP}))
+	assert.True(t, reflinkUnsupported(ErrUnsupported))
+	assert.False(t, reflinkUnsupported(&os.PathError{Op: "open", Path: "x", Err: syscall.EACCES}))
+	assert.False(t, reflinkUnsupported(nil))
+}
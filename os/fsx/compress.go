@@ -0,0 +1,214 @@
+package fsx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CompressFormat is a single file compression format.
+type CompressFormat string
+
+const (
+	FormatGzip  CompressFormat = "gzip"
+	FormatZlib  CompressFormat = "zlib"
+	FormatBzip2 CompressFormat = "bzip2" // decompression only
+)
+
+// compression levels accepted by Compress, same values as compress/flate
+const (
+	DefaultCompression = gzip.DefaultCompression
+	BestSpeed          = gzip.BestSpeed
+	BestCompression    = gzip.BestCompression
+)
+
+// Ext returns the conventional file extension of the format.
+func (f CompressFormat) Ext() string {
+	switch f {
+	case FormatGzip:
+		return ".gz"
+	case FormatZlib:
+		return ".zz"
+	case FormatBzip2:
+		return ".bz2"
+	}
+	return ""
+}
+
+// DetectCompression detects the format from the leading magic bytes of header.
+func DetectCompression(header []byte) (CompressFormat, error) {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FormatGzip, nil
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return FormatBzip2, nil
+	case len(header) >= 2 && header[0]&0x0f == 8 && header[0]>>4 <= 7 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0:
+		return FormatZlib, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// NewCompressWriter returns a writer compressing to w, closing it flushes the stream but does not close w.
+func NewCompressWriter(w io.Writer, format CompressFormat, level int) (io.WriteCloser, error) {
+	switch format {
+	case FormatGzip:
+		return gzip.NewWriterLevel(w, level)
+	case FormatZlib:
+		return zlib.NewWriterLevel(w, level)
+	case FormatBzip2:
+		return nil, fmt.Errorf("%s compression: %w", format, ErrUnsupported)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// NewDecompressReader detects the format of r and returns a reader of the decompressed data.
+// Closing it does not close r.
+func NewDecompressReader(r io.Reader) (io.ReadCloser, CompressFormat, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(3)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", err
+	}
+
+	format, err := DetectCompression(header)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case FormatGzip:
+		zr, err := gzip.NewReader(br)
+		return zr, format, err
+	case FormatZlib:
+		zr, err := zlib.NewReader(br)
+		return zr, format, err
+	default:
+		return io.NopCloser(bzip2.NewReader(br)), format, nil
+	}
+}
+
+// Compress writes the file compressed to the same path plus the format extension,
+// e.g. "app.log" to "app.log.gz". The original is kept and the mtime is carried over.
+func (fs *FS) Compress(format CompressFormat, level int) (*FS, error) {
+	return fs.CompressContext(context.Background(), format, level, nil)
+}
+
+// CompressContext is Compress with cancellation and progress events.
+func (fs *FS) CompressContext(ctx context.Context, format CompressFormat, level int, progress ProgressFunc) (_ *FS, err error) {
+	dst := fs.path + format.Ext()
+	defer func() { err = wrapErr("compress", fs.path, dst, err) }()
+
+	if _, err := os.Lstat(dst); err == nil {
+		return nil, ErrExists
+	}
+
+	src, err := fs.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracker(ctx, "compress", info.Size(), progress)
+	t.file(fs.path)
+	err = writeAtomic(dst, info.Mode().Perm(), func(w io.Writer) error {
+		cw, err := NewCompressWriter(w, format, level)
+		if err != nil {
+			return err
+		}
+		if gw, ok := cw.(*gzip.Writer); ok {
+			gw.Name, gw.ModTime = fs.Base(), info.ModTime()
+		}
+		if _, err := io.Copy(cw, t.reader(src)); err != nil {
+			return err
+		}
+		return cw.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return nil, err
+	}
+	t.finish()
+	return New(dst)
+}
+
+// Decompress detects the format of the file and writes the decompressed data next to it with the
+// format extension removed, "app.log.gz" to "app.log", or ".out" appended when the name has no
+// known extension. The original is kept and the mtime is carried over.
+func (fs *FS) Decompress() (*FS, error) {
+	return fs.DecompressContext(context.Background(), nil)
+}
+
+// DecompressContext is Decompress with cancellation and progress events, Total is the compressed size.
+func (fs *FS) DecompressContext(ctx context.Context, progress ProgressFunc) (_ *FS, err error) {
+	dst := ""
+	defer func() { err = wrapErr("decompress", fs.path, dst, err) }()
+
+	src, err := fs.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracker(ctx, "decompress", info.Size(), progress)
+	t.file(fs.path)
+	r, format, err := NewDecompressReader(t.reader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	dst = decompressedPath(fs.path, format)
+	if _, err := os.Lstat(dst); err == nil {
+		return nil, ErrExists
+	}
+
+	// nolint G110 // ignore G110: Potential DoS vulnerability via decompression bomb
+	if err := writeFileAtomic(dst, r, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return nil, err
+	}
+	t.finish()
+	return New(dst)
+}
+
+func decompressedPath(path string, format CompressFormat) string {
+	exts := []string{format.Ext()}
+	switch format {
+	case FormatGzip:
+		exts = append(exts, ".gzip", ".z")
+	case FormatZlib:
+		exts = append(exts, ".zlib", ".z")
+	case FormatBzip2:
+		exts = append(exts, ".bz")
+	}
+
+	lower := strings.ToLower(path)
+	for _, ext := range exts {
+		if strings.HasSuffix(lower, ext) && len(path) > len(ext) {
+			return path[:len(path)-len(ext)]
+		}
+	}
+	return path + ".out"
+}
@@ -0,0 +1,76 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_CompressDecompress(t *testing.T) {
+	content := strings.Repeat("2023-05-01 INFO request served\n", 1000)
+	mtime := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []CompressFormat{FormatGzip, FormatZlib} {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "app.log")
+		assert.NoError(t, os.WriteFile(src, []byte(content), 0o640))
+		assert.NoError(t, os.Chtimes(src, mtime, mtime))
+
+		fs, err := New(src)
+		assert.NoError(t, err)
+		compressed, err := fs.Compress(format, BestCompression)
+		assert.NoError(t, err, format)
+		assert.Equal(t, src+format.Ext(), compressed.Path())
+
+		_, err = fs.Compress(format, BestCompression)
+		assert.Error(t, err, "existing output must not be overwritten")
+
+		assert.NoError(t, os.Remove(src))
+		decompressed, err := compressed.Decompress()
+		assert.NoError(t, err, format)
+		assert.Equal(t, src, decompressed.Path())
+
+		got, err := os.ReadFile(src)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(got))
+
+		info, err := os.Stat(src)
+		assert.NoError(t, err)
+		assert.True(t, mtime.Equal(info.ModTime()))
+	}
+}
+
+func TestFS_DecompressBzip2(t *testing.T) {
+	data := []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xab, 0x6b,
+		0xa1, 0xf1, 0x00, 0x00, 0x02, 0xd9, 0x80, 0x00, 0x10, 0x40, 0x00, 0x10,
+		0x00, 0x12, 0x64, 0xc0, 0x10, 0x20, 0x00, 0x31, 0x00, 0xd3, 0x4d, 0x04,
+		0x00, 0x1e, 0xa3, 0xef, 0x4e, 0x51, 0xa2, 0x07, 0x8b, 0xb9, 0x22, 0x9c,
+		0x28, 0x48, 0x55, 0xb5, 0xd0, 0xf8, 0x80,
+	}
+	src := filepath.Join(t.TempDir(), "note")
+	assert.NoError(t, os.WriteFile(src, data, 0o644))
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+	out, err := fs.Decompress()
+	assert.NoError(t, err)
+	assert.Equal(t, src+".out", out.Path())
+
+	got, err := os.ReadFile(out.Path())
+	assert.NoError(t, err)
+	assert.Equal(t, "hello bzip2\n", string(got))
+}
+
+func TestDetectCompression(t *testing.T) {
+	_, err := DetectCompression([]byte("plain text"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	format, err := DetectCompression([]byte{0x78, 0x9c})
+	assert.NoError(t, err)
+	assert.Equal(t, FormatZlib, format)
+}
@@ -0,0 +1,34 @@
+package fsx
+
+import (
+	"io"
+	"os"
+)
+
+// copyFile copies a regular file to dst with the mode and mtime of info.
+func copyFile(src, dst string, info os.FileInfo) (err error) {
+	defer func() { err = wrapErr("copy", src, dst, err) }()
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
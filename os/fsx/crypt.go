@@ -0,0 +1,368 @@
+package fsx
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Encrypted files start with a header which is authenticated as additional data of every chunk:
+//
+//	magic "FSXE" | version (1) | kdf (1) | chunk size (4) | kdf iterations (4) | salt (16) | nonce prefix (7)
+//
+// followed by AES-256-GCM sealed chunks. The nonce of chunk n is prefix | n (4) | last flag (1),
+// so reordered, dropped or truncated chunks fail to open.
+const (
+	cryptMagic        = "FSXE"
+	cryptVersion      = 1
+	cryptSaltSize     = 16
+	cryptPrefixSize   = 7
+	cryptHeaderSize   = len(cryptMagic) + 1 + 1 + 4 + 4 + cryptSaltSize + cryptPrefixSize
+	cryptChunkSize    = 64 * 1024
+	cryptMaxChunkSize = 16 * 1024 * 1024
+	cryptMaxIter      = 10_000_000
+
+	kdfRaw    = 0
+	kdfPBKDF2 = 1
+
+	// DefaultKDFIterations is the PBKDF2-SHA256 work factor used for passphrase keys.
+	DefaultKDFIterations = 600_000
+)
+
+// EncryptionKey is the secret used by Encrypt and Decrypt.
+type EncryptionKey struct {
+	secret     []byte
+	passphrase bool
+	iterations int
+}
+
+// RawKey uses a random key of at least 16 bytes, a per file key is derived from it with HKDF-SHA256.
+func RawKey(key []byte) *EncryptionKey {
+	return &EncryptionKey{secret: key}
+}
+
+// PassphraseKey derives the key from a passphrase with salted PBKDF2-SHA256.
+func PassphraseKey(passphrase string) *EncryptionKey {
+	return &EncryptionKey{secret: []byte(passphrase), passphrase: true, iterations: DefaultKDFIterations}
+}
+
+// WithIterations sets the PBKDF2 work factor of a passphrase key, decryption reads it from the header.
+func (k *EncryptionKey) WithIterations(n int) *EncryptionKey {
+	k.iterations = n
+	return k
+}
+
+func (k *EncryptionKey) derive(kdf byte, iterations int, salt []byte) ([]byte, error) {
+	switch {
+	case kdf == kdfRaw && !k.passphrase:
+		if len(k.secret) < 16 {
+			return nil, fmt.Errorf("%w: raw key must be at least 16 bytes", ErrUnsupportedKey)
+		}
+		// HKDF-Extract, the pseudorandom key is exactly the 32 bytes we need
+		mac := hmac.New(sha256.New, salt)
+		mac.Write(k.secret)
+		return mac.Sum(nil), nil
+	case kdf == kdfPBKDF2 && k.passphrase:
+		if iterations < 1 || iterations > cryptMaxIter {
+			return nil, fmt.Errorf("%w: invalid iterations %d", ErrUnsupportedKey, iterations)
+		}
+		return pbkdf2Key(sha256.New, k.secret, salt, iterations, 32), nil
+	}
+	return nil, fmt.Errorf("%w: key type does not match the file", ErrUnsupportedKey)
+}
+
+type cryptHeader struct {
+	raw        []byte
+	kdf        byte
+	chunkSize  int
+	iterations int
+	salt       []byte
+	prefix     []byte
+}
+
+func newCryptHeader(key *EncryptionKey) (*cryptHeader, error) {
+	h := &cryptHeader{raw: make([]byte, cryptHeaderSize), chunkSize: cryptChunkSize}
+	if key.passphrase {
+		h.kdf, h.iterations = kdfPBKDF2, key.iterations
+	}
+
+	copy(h.raw, cryptMagic)
+	b := h.raw[len(cryptMagic):]
+	b[0], b[1] = cryptVersion, h.kdf
+	binary.BigEndian.PutUint32(b[2:], uint32(h.chunkSize))
+	binary.BigEndian.PutUint32(b[6:], uint32(h.iterations))
+	h.salt = b[10 : 10+cryptSaltSize]
+	h.prefix = b[10+cryptSaltSize:]
+	if _, err := io.ReadFull(rand.Reader, b[10:]); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func readCryptHeader(r io.Reader) (*cryptHeader, error) {
+	h := &cryptHeader{raw: make([]byte, cryptHeaderSize)}
+	if _, err := io.ReadFull(r, h.raw); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+	if string(h.raw[:len(cryptMagic)]) != cryptMagic {
+		return nil, ErrNotEncrypted
+	}
+
+	b := h.raw[len(cryptMagic):]
+	if b[0] != cryptVersion {
+		return nil, fmt.Errorf("unsupported encryption format version %d", b[0])
+	}
+	h.kdf = b[1]
+	h.chunkSize = int(binary.BigEndian.Uint32(b[2:]))
+	h.iterations = int(binary.BigEndian.Uint32(b[6:]))
+	h.salt = b[10 : 10+cryptSaltSize]
+	h.prefix = b[10+cryptSaltSize:]
+	if h.chunkSize < 1 || h.chunkSize > cryptMaxChunkSize {
+		return nil, fmt.Errorf("%w: invalid chunk size %d", ErrDecrypt, h.chunkSize)
+	}
+	return h, nil
+}
+
+func (h *cryptHeader) aead(key *EncryptionKey) (cipher.AEAD, error) {
+	k, err := key.derive(h.kdf, h.iterations, h.salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (h *cryptHeader) nonce(counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, h.prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	header  *cryptHeader
+	aead    cipher.AEAD
+	buf     []byte
+	out     []byte
+	counter uint32
+	closed  bool
+}
+
+// NewEncryptWriter returns a writer encrypting to w, Close must be called to write the final chunk.
+// Closing it does not close w.
+func NewEncryptWriter(w io.Writer, key *EncryptionKey) (io.WriteCloser, error) {
+	header, err := newCryptHeader(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := header.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header.raw); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, header: header, aead: aead, buf: make([]byte, 0, header.chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, os.ErrClosed
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// a full chunk is only sealed once more data arrives, the last one must carry the last flag
+		if len(e.buf) == e.header.chunkSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(e.buf[len(e.buf):e.header.chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.counter == ^uint32(0) {
+		return errors.New("encrypt: too many chunks")
+	}
+	e.out = e.aead.Seal(e.out[:0], e.header.nonce(e.counter, last), e.buf, e.header.raw)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	header  *cryptHeader
+	aead    cipher.AEAD
+	buf     []byte
+	plain   []byte
+	counter uint32
+	done    bool
+}
+
+// NewDecryptReader returns a reader of the decrypted content of r. Every chunk is authenticated
+// before it is returned and a missing final chunk is reported as ErrDecrypt.
+func NewDecryptReader(r io.Reader, key *EncryptionKey) (io.Reader, error) {
+	header, err := readCryptHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := header.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		header: header,
+		aead:   aead,
+		buf:    make([]byte, header.chunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	switch {
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: missing final chunk", ErrDecrypt)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+	case err != nil:
+		return err
+	}
+
+	last := n < len(d.buf)
+	if !last {
+		if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	d.plain, err = d.aead.Open(d.buf[:0], d.header.nonce(d.counter, last), d.buf[:n], d.header.raw)
+	if err != nil {
+		return ErrDecrypt
+	}
+	d.counter++
+	d.done = last
+	return nil
+}
+
+// Encrypt writes the encrypted file to dst, the output is put in place atomically.
+func (fs *FS) Encrypt(dst string, key *EncryptionKey) error {
+	return fs.EncryptContext(context.Background(), dst, key, nil)
+}
+
+// EncryptContext is Encrypt with cancellation and progress events.
+func (fs *FS) EncryptContext(ctx context.Context, dst string, key *EncryptionKey, progress ProgressFunc) (err error) {
+	defer func() { err = wrapErr("encrypt", fs.path, dst, err) }()
+
+	src, err := fs.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	t := newTracker(ctx, "encrypt", info.Size(), progress)
+	t.file(fs.path)
+	err = writeAtomic(dst, 0o600, func(w io.Writer) error {
+		ew, err := NewEncryptWriter(w, key)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(ew, t.reader(src)); err != nil {
+			return err
+		}
+		return ew.Close()
+	})
+	if err != nil {
+		return err
+	}
+	t.finish()
+	return nil
+}
+
+// Decrypt writes the decrypted file to dst. Nothing is written unless the whole file authenticates.
+func (fs *FS) Decrypt(dst string, key *EncryptionKey) error {
+	return fs.DecryptContext(context.Background(), dst, key, nil)
+}
+
+// DecryptContext is Decrypt with cancellation and progress events.
+func (fs *FS) DecryptContext(ctx context.Context, dst string, key *EncryptionKey, progress ProgressFunc) (err error) {
+	defer func() { err = wrapErr("decrypt", fs.path, dst, err) }()
+
+	src, err := fs.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	t := newTracker(ctx, "decrypt", info.Size(), progress)
+	t.file(fs.path)
+	r, err := NewDecryptReader(t.reader(src), key)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(dst, r, 0o600); err != nil {
+		return err
+	}
+	t.finish()
+	return nil
+}
@@ -0,0 +1,88 @@
+package fsx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_EncryptDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 3*cryptChunkSize+17)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	src := filepath.Join(dir, "secret")
+	assert.NoError(t, os.WriteFile(src, data, 0o600))
+	fs, err := New(src)
+	assert.NoError(t, err)
+
+	keys := []*EncryptionKey{
+		RawKey(bytes.Repeat([]byte{7}, 32)),
+		PassphraseKey("correct horse battery staple").WithIterations(1000),
+	}
+	for _, key := range keys {
+		enc := filepath.Join(dir, "secret.enc")
+		assert.NoError(t, fs.Encrypt(enc, key))
+
+		encFs, err := New(enc)
+		assert.NoError(t, err)
+		out := filepath.Join(dir, "secret.out")
+		assert.NoError(t, encFs.Decrypt(out, key))
+
+		got, err := os.ReadFile(out)
+		assert.NoError(t, err)
+		assert.True(t, bytes.Equal(data, got))
+	}
+
+	enc := filepath.Join(dir, "secret.enc")
+	encFs, err := New(enc)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, encFs.Decrypt(filepath.Join(dir, "x"), PassphraseKey("wrong").WithIterations(1000)), ErrDecrypt)
+	assert.ErrorIs(t, encFs.Decrypt(filepath.Join(dir, "x"), keys[0]), ErrUnsupportedKey)
+
+	raw, err := os.ReadFile(enc)
+	assert.NoError(t, err)
+	chunk := cryptChunkSize + 16
+
+	// truncated at a chunk boundary
+	assert.NoError(t, os.WriteFile(enc, raw[:cryptHeaderSize+2*chunk], 0o600))
+	assert.ErrorIs(t, encFs.Decrypt(filepath.Join(dir, "x"), keys[1]), ErrDecrypt)
+
+	// chunks swapped
+	swapped := append([]byte{}, raw[:cryptHeaderSize]...)
+	swapped = append(swapped, raw[cryptHeaderSize+chunk:cryptHeaderSize+2*chunk]...)
+	swapped = append(swapped, raw[cryptHeaderSize:cryptHeaderSize+chunk]...)
+	swapped = append(swapped, raw[cryptHeaderSize+2*chunk:]...)
+	assert.NoError(t, os.WriteFile(enc, swapped, 0o600))
+	assert.ErrorIs(t, encFs.Decrypt(filepath.Join(dir, "x"), keys[1]), ErrDecrypt)
+
+	_, err = os.Stat(filepath.Join(dir, "x"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEncryptWriter_Empty(t *testing.T) {
+	key := RawKey(bytes.Repeat([]byte{1}, 16))
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := NewDecryptReader(&buf, key)
+	assert.NoError(t, err)
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914 section 11
+	got := pbkdf2Key(sha256.New, []byte("passwd"), []byte("salt"), 1, 64)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783", hex.EncodeToString(got))
+}
@@ -0,0 +1,214 @@
+package fsx
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	a, b int  // line index in a and b, the position before which an insertion or after which a deletion happens
+}
+
+// unifiedDiff returns a unified diff of two texts with three lines of context, "" when equal.
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+	ops := diffLines(al, bl)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(ops); {
+		// find the next change and extend the hunk while changes are close together
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+		to := last + diffContext + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+		writeHunk(&out, ops[from:to], al, bl)
+		start = to
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp, a, b []string) {
+	aStart, bStart, aLen, bLen := ops[0].a, ops[0].b, 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			aLen++
+		}
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+	// an empty range starts at the line before it
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+
+	for _, op := range ops {
+		line := ""
+		switch op.kind {
+		case '+':
+			line = b[op.b]
+		default:
+			line = a[op.a]
+		}
+		out.WriteByte(op.kind)
+		out.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script with the linear space variant of Myers' O(ND)
+// algorithm, splitting at the middle snake and recursing on both halves.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffRange(a, b, 0, 0, &ops)
+	return ops
+}
+
+// diffRange appends the ops turning a into b, which start at line a0 and b0 of the whole texts.
+func diffRange(a, b []string, a0, b0 int, ops *[]diffOp) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		*ops = append(*ops, diffOp{kind: ' ', a: a0 + prefix, b: b0 + prefix})
+		prefix++
+	}
+	a, b, a0, b0 = a[prefix:], b[prefix:], a0+prefix, b0+prefix
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	n, m := len(a)-suffix, len(b)-suffix
+
+	switch {
+	case n == 0:
+		for y := 0; y < m; y++ {
+			*ops = append(*ops, diffOp{kind: '+', a: a0, b: b0 + y})
+		}
+	case m == 0:
+		for x := 0; x < n; x++ {
+			*ops = append(*ops, diffOp{kind: '-', a: a0 + x, b: b0})
+		}
+	default:
+		x, y := middleSnake(a[:n], b[:m])
+		diffRange(a[:x], b[:y], a0, b0, ops)
+		diffRange(a[x:n], b[y:m], a0+x, b0+y, ops)
+	}
+
+	for i := 0; i < suffix; i++ {
+		*ops = append(*ops, diffOp{kind: ' ', a: a0 + n + i, b: b0 + m + i})
+	}
+}
+
+// middleSnake runs the forward and backward searches until they overlap and returns the point on
+// a shortest edit path where they meet. a and b differ in their first and in their last line.
+func middleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset, size := maxD, 2*maxD+2
+	vf, vb := make([]int, size), make([]int, size)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	front := delta%2 != 0
+	// diagonals leaving the grid on the right or bottom are not extended again
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && vf[i-1] < vf[i+1]) {
+				x = vf[i+1]
+			} else {
+				x = vf[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case front:
+				if j := offset + delta - k; j >= 0 && j < size && vb[j] != -1 && x >= n-vb[j] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && vb[i-1] < vb[i+1]) {
+				x = vb[i+1]
+			} else {
+				x = vb[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			vb[i] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !front:
+				if j := offset + delta - k; j >= 0 && j < size && vf[j] != -1 && vf[j] >= n-x {
+					return vf[j], vf[j] - (j - offset)
+				}
+			}
+		}
+	}
+	// no overlap only happens without common lines, delete all of a and insert all of b
+	return n, 0
+}
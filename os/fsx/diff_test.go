@@ -0,0 +1,31 @@
+package fsx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Equal(t, "", unifiedDiff("a", "b", "x\n", "x\n"))
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", unifiedDiff("a", "b", "a\nb\nc\n", "a\nB\nc\n"))
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n", unifiedDiff("a", "b", "", "x\n"))
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-x\n+y\n\\ No newline at end of file\n", unifiedDiff("a", "b", "x\n", "y"))
+
+	// a large file with edits everywhere, one hunk per edit
+	var a, b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&a, "line %d\n", i)
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "edited %d\n", i)
+		} else {
+			fmt.Fprintf(&b, "line %d\n", i)
+		}
+	}
+	diff := unifiedDiff("a", "b", a.String(), b.String())
+	assert.Equal(t, 2000, strings.Count(diff, "\n-line "))
+	assert.Equal(t, 2000, strings.Count(diff, "\n+edited "))
+	assert.Equal(t, 2000, strings.Count(diff, "@@ -"))
+}
@@ -0,0 +1,63 @@
+package fsx
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Errors returned by fsx operations, wrapped in a *PathError. Test for them with errors.Is, ErrExists
+// and ErrNotExist also match fs.ErrExist and fs.ErrNotExist.
+var (
+	ErrExists           = &sentinel{"file already exists", fs.ErrExist}
+	ErrNotExist         = &sentinel{"file does not exist", fs.ErrNotExist}
+	ErrNotDir           = errors.New("not a directory")
+	ErrUnsafePath       = errors.New("unsafe path")
+	ErrArchiveTooLarge  = errors.New("archive too large")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrInvalid          = &sentinel{"invalid argument", fs.ErrInvalid}
+	ErrUnsupported      = errors.New("not supported")
+	ErrPassword         = errors.New("wrong or missing password")
+	ErrUnknownFormat    = errors.New("unknown compression format") // neither given nor detected
+	ErrDecrypt          = errors.New("decryption failed: wrong key or corrupt data")
+	ErrNotEncrypted     = errors.New("not an fsx encrypted file")  // no fsx encryption header
+	ErrUnsupportedKey   = errors.New("unsupported encryption key") // too short, bad iterations or wrong key type
+)
+
+type sentinel struct {
+	msg string
+	is  error
+}
+
+func (e *sentinel) Error() string { return e.msg }
+func (e *sentinel) Unwrap() error { return e.is }
+
+// PathError records a failed operation with its path, and Dst for operations on two paths such as
+// "zip" or "copy".
+type PathError struct {
+	Op   string
+	Path string
+	Dst  string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	if e.Dst == "" {
+		return e.Op + " " + e.Path + ": " + e.Err.Error()
+	}
+	return e.Op + " " + e.Path + " " + e.Dst + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error { return e.Err }
+
+// wrapErr wraps err in a *PathError, nil stays nil and an error already carrying the same
+// operation is returned as is.
+func wrapErr(op, path, dst string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *PathError
+	if errors.As(err, &pe) && pe.Op == op {
+		return err
+	}
+	return &PathError{Op: op, Path: path, Dst: dst, Err: err}
+}
@@ -0,0 +1,101 @@
+package fsx
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathError(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTestTree(t, src, map[string]string{"a.txt": "alpha\n"})
+	archive := filepath.Join(dir, "src.zip")
+	assert.NoError(t, os.WriteFile(archive, nil, 0o644))
+
+	srcFs, err := New(src)
+	assert.NoError(t, err)
+	err = srcFs.Zip(archive)
+	assert.ErrorIs(t, err, ErrExists)
+	assert.ErrorIs(t, err, fs.ErrExist)
+	var pe *PathError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, "zip", pe.Op)
+		assert.Equal(t, src, pe.Path)
+		assert.Equal(t, archive, pe.Dst)
+	}
+	assert.Equal(t, "zip "+src+" "+archive+": file already exists", err.Error())
+
+	missing, err := New(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	_, err = missing.Sha256()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, "sha256", pe.Op)
+	_, err = missing.Mode()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.False(t, missing.IsFile())
+}
+
+func TestPathError_Ops(t *testing.T) {
+	dir := t.TempDir()
+	missing, err := New(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	dst, err := New(filepath.Join(dir, "dst"))
+	assert.NoError(t, err)
+
+	_, replaceErr := missing.Replace(regexp.MustCompile("a"), "b", ReplaceOptions{})
+	_, scaffoldErr := missing.Scaffold(dst.Path(), ScaffoldOptions{})
+	_, modeErr := missing.ModeString()
+	_, grepErr := missing.Grep(context.Background(), "(", GrepOptions{})
+	_, extractErr := Extract(os.DirFS(dir), "missing", dst, ExtractOptions{})
+	_, configErr := FindConfig("fsx-test-missing/config.toml")
+	tests := map[string]error{
+		"replace":     replaceErr,
+		"scaffold":    scaffoldErr,
+		"mode":        modeErr,
+		"grep":        grepErr,
+		"extract":     extractErr,
+		"chmod":       missing.ChmodSymbolic("u+x"),
+		"chown":       missing.ChownRecursive(-1, -1),
+		"find config": configErr,
+	}
+	for op, err := range tests {
+		var pe *PathError
+		if assert.True(t, errors.As(err, &pe), op) {
+			assert.Equal(t, op, pe.Op)
+		}
+	}
+	assert.ErrorIs(t, configErr, ErrNotExist)
+}
+
+func TestFS_UnzipErrors(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.zip")
+	f, err := os.Create(archive)
+	assert.NoError(t, err)
+	w := zip.NewWriter(f)
+	entry, err := w.Create("../evil.txt")
+	assert.NoError(t, err)
+	_, err = entry.Write([]byte("0123456789"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, f.Close())
+
+	zfs, err := New(archive)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, zfs.Unzip(filepath.Join(dir, "out")), ErrUnsafePath)
+	assert.NoFileExists(t, filepath.Join(dir, "evil.txt"))
+
+	opts := UnzipOptions{MaxSize: 4}
+	assert.ErrorIs(t, zfs.UnzipWith(context.Background(), filepath.Join(dir, "out"), opts), ErrArchiveTooLarge)
+	_, err = zfs.UnzipMatchWith(context.Background(), filepath.Join(dir, "out"), opts, "*.txt")
+	assert.ErrorIs(t, err, ErrArchiveTooLarge)
+}
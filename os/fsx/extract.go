@@ -0,0 +1,169 @@
+package fsx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModeRule sets the mode of files matching Pattern, a glob where "**" matches any number of
+// directories. Patterns without a slash match the base name, others the whole slash separated
+// path relative to the extract root.
+type ModeRule struct {
+	Pattern string
+	Mode    fs.FileMode
+}
+
+// ExtractOptions configures Extract.
+type ExtractOptions struct {
+	// Modes sets the mode of single files by their path relative to root, it wins over ModeRules.
+	Modes map[string]fs.FileMode
+	// ModeRules are tried in order, the first match wins.
+	ModeRules []ModeRule
+	// FileMode is used for files without a mode, defaults to 0644.
+	FileMode fs.FileMode
+	// DirMode is used for created directories, defaults to 0755.
+	DirMode fs.FileMode
+	// KeepExisting leaves existing files with different content alone instead of replacing them.
+	KeepExisting bool
+}
+
+// ExtractReport lists the files Extract wrote and skipped, relative to the destination.
+type ExtractReport struct {
+	Written []string
+	Skipped []string
+}
+
+// Extract materialises the tree under root of src, e.g. an embed.FS, into dst. Files already
+// present with identical content are skipped, names escaping dst are rejected like in Unzip.
+func Extract(src fs.FS, root string, dst *FS, opts ExtractOptions) (*ExtractReport, error) {
+	return ExtractContext(context.Background(), src, root, dst, opts, nil)
+}
+
+// ExtractContext is Extract with cancellation and progress events, files written so far stay.
+// The total of the events is unknown.
+func ExtractContext(ctx context.Context, src fs.FS, root string, dst *FS, opts ExtractOptions, progress ProgressFunc) (_ *ExtractReport, err error) {
+	defer func() { err = wrapErr("extract", root, dst.path, err) }()
+
+	if opts.FileMode == 0 {
+		opts.FileMode = 0o644
+	}
+	if opts.DirMode == 0 {
+		opts.DirMode = 0o755
+	}
+	if root == "" {
+		root = "."
+	}
+
+	t := newTracker(ctx, "extract", 0, progress)
+	report := &ExtractReport{}
+	err = fs.WalkDir(src, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := t.err(); err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		if root == "." {
+			rel = name
+		}
+		if rel == "" || rel == "." {
+			return os.MkdirAll(dst.path, opts.DirMode)
+		}
+
+		target, err := safeJoin(dst.path, rel)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, opts.DirMode)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		t.file(rel)
+		written, err := extractFile(src, name, target, opts.extractMode(rel), opts.KeepExisting, t)
+		if err != nil {
+			return err
+		}
+		if written {
+			report.Written = append(report.Written, rel)
+		} else {
+			report.Skipped = append(report.Skipped, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.finish()
+	return report, nil
+}
+
+func (opts ExtractOptions) extractMode(rel string) fs.FileMode {
+	if mode, ok := opts.Modes[rel]; ok {
+		return mode
+	}
+	for _, rule := range opts.ModeRules {
+		if matchGlob(rule.Pattern, rel) {
+			return rule.Mode
+		}
+	}
+	return opts.FileMode
+}
+
+func extractFile(src fs.FS, name, target string, mode fs.FileMode, keepExisting bool, t *tracker) (bool, error) {
+	if info, err := os.Lstat(target); err == nil {
+		if keepExisting {
+			return false, nil
+		}
+		same, err := sameContent(src, name, target)
+		if err != nil {
+			return false, err
+		}
+		if same {
+			if info.Mode().Perm() != mode.Perm() {
+				return false, os.Chmod(target, mode)
+			}
+			return false, nil
+		}
+	}
+
+	in, err := src.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return false, err
+	}
+	return true, writeFileAtomic(target, t.reader(in), mode)
+}
+
+func sameContent(src fs.FS, name, target string) (bool, error) {
+	existing, err := fileSha256(target)
+	if err != nil {
+		return false, err
+	}
+
+	in, err := src.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) == existing, nil
+}
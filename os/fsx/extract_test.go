@@ -0,0 +1,60 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	src := fstest.MapFS{
+		"assets/config.yaml":      {Data: []byte("port: 80\n")},
+		"assets/bin/start.sh":     {Data: []byte("#!/bin/sh\n")},
+		"assets/secrets/key.pem":  {Data: []byte("key")},
+		"assets/static/index.css": {Data: []byte("body{}")},
+		"other/ignored.txt":       {Data: []byte("x")},
+	}
+
+	dst, err := New(filepath.Join(t.TempDir(), "app"))
+	assert.NoError(t, err)
+	opts := ExtractOptions{
+		Modes:     map[string]os.FileMode{"secrets/key.pem": 0o600},
+		ModeRules: []ModeRule{{Pattern: "*.sh", Mode: 0o755}},
+	}
+
+	report, err := Extract(src, "assets", dst, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bin/start.sh", "config.yaml", "secrets/key.pem", "static/index.css"}, report.Written)
+	assert.Empty(t, report.Skipped)
+
+	for name, mode := range map[string]os.FileMode{"bin/start.sh": 0o755, "secrets/key.pem": 0o600, "config.yaml": 0o644} {
+		info, err := os.Stat(filepath.Join(dst.Path(), filepath.FromSlash(name)))
+		assert.NoError(t, err)
+		assert.Equal(t, mode, info.Mode().Perm(), name)
+	}
+	_, err = os.Stat(filepath.Join(dst.Path(), "ignored.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dst.Path(), "config.yaml"), []byte("port: 8080\n"), 0o644))
+	report, err = Extract(src, "assets", dst, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config.yaml"}, report.Written)
+	assert.Len(t, report.Skipped, 3)
+}
+
+func TestSafeJoin(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"../evil", "a/../../evil", "/../evil", "..\\evil"} {
+		_, err := safeJoin(dir, name)
+		assert.Error(t, err, name)
+	}
+	path, err := safeJoin(dir, "/etc/passwd")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "etc", "passwd"), path)
+	path, err = safeJoin(dir, "a/b/../c")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "a", "c"), path)
+}
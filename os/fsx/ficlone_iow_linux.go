@@ -0,0 +1,6 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le || ppc64 || ppc64le)
+
+package fsx
+
+// ficlone is the FICLONE ioctl, _IOW(0x94, 9, int) with the write direction bit of mips and ppc.
+const ficlone = 0x80049409
@@ -2,15 +2,19 @@ package fsx
 
 import (
 	"archive/zip"
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
-	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
-	"strings"
+
+	"github.com/rogeecn/tl/units"
 )
 
 type FS struct {
@@ -126,151 +130,193 @@ func (fs *FS) ChownUid(uid int) error {
 
 // cal file md5 hash
 func (fs *FS) Md5() (string, error) {
-	file, err := fs.Open()
+	return fs.Md5Context(context.Background(), nil)
+}
+
+// Md5Context is Md5 with cancellation and progress events.
+func (fs *FS) Md5Context(ctx context.Context, progress ProgressFunc) (string, error) {
+	return fs.hashContext(ctx, "md5", md5.New(), progress)
+}
+
+// cal file sha256 hash
+func (fs *FS) Sha256() (string, error) {
+	return fs.Sha256Context(context.Background(), nil)
+}
+
+// Sha256Context is Sha256 with cancellation and progress events.
+func (fs *FS) Sha256Context(ctx context.Context, progress ProgressFunc) (string, error) {
+	return fs.hashContext(ctx, "sha256", sha256.New(), progress)
+}
+
+func (fs *FS) hashContext(ctx context.Context, op string, hash hash.Hash, progress ProgressFunc) (sum string, err error) {
+	defer func() { err = wrapErr(op, fs.path, "", err) }()
+
+	info, err := os.Stat(fs.path)
 	if err != nil {
 		return "", err
 	}
-	hash := md5.New()
-	_, _ = io.Copy(hash, file)
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	t := newTracker(ctx, op, info.Size(), progress)
+	if sum, err = fs.hashTracked(hash, t); err != nil {
+		return "", err
+	}
+	t.finish()
+	return sum, nil
 }
 
-// cal file sha256 hash
-func (fs *FS) Sha256() (string, error) {
+// hashTracked hashes the file, counting the bytes read on t.
+func (fs *FS) hashTracked(hash hash.Hash, t *tracker) (string, error) {
 	file, err := fs.Open()
 	if err != nil {
 		return "", err
 	}
-	hash := sha256.New()
-	_, _ = io.Copy(hash, file)
+	defer file.Close()
+
+	t.file(fs.path)
+	if _, err := io.Copy(hash, t.reader(file)); err != nil {
+		return "", err
+	}
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// Stat stats the file again and refreshes the info cached by State.
+func (fs *FS) Stat() (os.FileInfo, error) {
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	fs.fileInfo = info
+	return info, nil
+}
+
+// Mode returns the current mode of the file. The Is predicates report false when the stat fails,
+// Mode tells a missing file from e.g. a permission error.
+func (fs *FS) Mode() (os.FileMode, error) {
+	info, err := fs.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode(), nil
+}
+
 // is file or directory
 func (fs *FS) IsDir() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.IsDir()
+	mode, err := fs.Mode()
+	return err == nil && mode.IsDir()
 }
 
 // is file
 func (fs *FS) IsFile() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return !fs.fileInfo.IsDir()
+	mode, err := fs.Mode()
+	return err == nil && !mode.IsDir()
 }
 
 // is symlink
 func (fs *FS) IsSymlink() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeSymlink != 0
+	info, err := os.Lstat(fs.path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
 }
 
 // is socket
 func (fs *FS) IsSocket() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeSocket != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeSocket != 0
 }
 
 // is named pipe
 func (fs *FS) IsNamedPipe() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeNamedPipe != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeNamedPipe != 0
 }
 
 // is character device
 func (fs *FS) IsCharDevice() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeCharDevice != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeCharDevice != 0
 }
 
 // is block device
 func (fs *FS) IsBlockDevice() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeDevice != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeDevice != 0
 }
 
 // is setuid
 func (fs *FS) IsSetuid() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeSetuid != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeSetuid != 0
 }
 
 // is setgid
 func (fs *FS) IsSetgid() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeSetgid != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeSetgid != 0
 }
 
 // is sticky
 func (fs *FS) IsSticky() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeSticky != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeSticky != 0
 }
 
 // is regular
 func (fs *FS) IsRegular() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode().IsRegular()
+	mode, err := fs.Mode()
+	return err == nil && mode.IsRegular()
 }
 
 // is append-only
 func (fs *FS) IsAppend() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeAppend != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeAppend != 0
 }
 
 // is exclusive use
 func (fs *FS) IsExclusive() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeExclusive != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeExclusive != 0
 }
 
 // is temporary
 func (fs *FS) IsTemporary() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeTemporary != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeTemporary != 0
 }
 
 // is device
 func (fs *FS) IsDevice() bool {
-	if !fs.Exists() {
-		return false
-	}
-	return fs.fileInfo.Mode()&os.ModeDevice != 0
+	mode, err := fs.Mode()
+	return err == nil && mode&os.ModeDevice != 0
 }
 
 // zip dir
 func (fs *FS) Zip(file string) error {
-	_, err := os.Stat(file)
-	if err == nil {
-		return errors.New("file already exists")
+	return fs.ZipContext(context.Background(), file, nil)
+}
+
+// ZipContext is Zip with cancellation and progress events, the archive is removed when it fails.
+func (fs *FS) ZipContext(ctx context.Context, file string, progress ProgressFunc) error {
+	return fs.ZipWith(ctx, file, ZipOptions{Progress: progress})
+}
+
+// ZipOptions configures ZipWith.
+type ZipOptions struct {
+	// Password encrypts every entry with Encryption when set.
+	Password   string
+	Encryption ZipEncryption
+	Progress   ProgressFunc
+}
+
+// ZipWith is Zip with options, the archive is removed when it fails.
+func (fs *FS) ZipWith(ctx context.Context, file string, opts ZipOptions) (err error) {
+	defer func() { err = wrapErr("zip", fs.path, file, err) }()
+
+	if _, err := os.Lstat(file); err == nil {
+		return ErrExists
+	}
+
+	total, err := treeSize(fs.path)
+	if err != nil {
+		return err
 	}
 
 	// zip a dir to a file
@@ -278,15 +324,23 @@ func (fs *FS) Zip(file string) error {
 	if err != nil {
 		return err
 	}
-	defer zipFile.Close()
+	defer func() {
+		zipFile.Close()
+		if err != nil {
+			os.Remove(file)
+		}
+	}()
 
 	w := zip.NewWriter(zipFile)
-	defer w.Close()
+	t := newTracker(ctx, "zip", total, opts.Progress)
 
 	walker := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := t.err(); err != nil {
+			return err
+		}
 		if info.IsDir() {
 			return nil
 		}
@@ -297,23 +351,104 @@ func (fs *FS) Zip(file string) error {
 		}
 		defer file.Close()
 
-		f, err := w.Create(strings.ReplaceAll(path, fs.path, ""))
+		name, err := filepath.Rel(fs.path, path)
 		if err != nil {
 			return err
 		}
+		if name == "." {
+			name = filepath.Base(path)
+		}
+
+		t.file(name)
+		if opts.Password != "" {
+			// info is from Lstat, the entry holds what the open file points to
+			stat, err := file.Stat()
+			if err != nil {
+				return err
+			}
+			return zipAddEncrypted(w, filepath.ToSlash(name), stat, t.reader(file), opts.Password, opts.Encryption)
+		}
 
-		_, err = io.Copy(f, file)
+		f, err := w.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, t.reader(file))
 		if err != nil {
 			return err
 		}
 		return nil
 	}
 
-	return filepath.Walk(fs.path, walker)
+	if err = filepath.Walk(fs.path, walker); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = zipFile.Close(); err != nil {
+		return err
+	}
+	t.finish()
+	return nil
+}
+
+// treeSize sums the size of the regular files under path.
+func treeSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// unzipSize returns the uncompressed size of files, failing with ErrArchiveTooLarge over max unless
+// it is 0. The zip reader rejects entries holding more data than their header declares.
+func unzipSize(files []*zip.File, max units.Base2Bytes) (int64, error) {
+	var total uint64
+	for _, f := range files {
+		total += f.UncompressedSize64
+		if total > math.MaxInt64 {
+			return 0, ErrArchiveTooLarge
+		}
+		if max > 0 && total > uint64(max) {
+			return 0, fmt.Errorf("%w: more than %s uncompressed", ErrArchiveTooLarge, max)
+		}
+	}
+	return int64(total), nil
 }
 
 // unzip to dst path
 func (fs *FS) Unzip(dst string) error {
+	return fs.UnzipContext(context.Background(), dst, nil)
+}
+
+// UnzipContext is Unzip with cancellation and progress events, files extracted so far are removed
+// when it fails.
+func (fs *FS) UnzipContext(ctx context.Context, dst string, progress ProgressFunc) error {
+	return fs.UnzipWith(ctx, dst, UnzipOptions{Progress: progress})
+}
+
+// UnzipOptions configures UnzipWith.
+type UnzipOptions struct {
+	// Password decrypts WinZip AES and ZipCrypto entries, a wrong one fails with ErrPassword.
+	Password string
+	// MaxSize limits the total uncompressed size extracted, 0 means no limit.
+	MaxSize  units.Base2Bytes
+	Progress ProgressFunc
+}
+
+// UnzipWith is Unzip with options, files extracted so far are removed when it fails, e.g. when an
+// AES entry fails authentication.
+func (fs *FS) UnzipWith(ctx context.Context, dst string, opts UnzipOptions) (err error) {
+	defer func() { err = wrapErr("unzip", fs.path, dst, err) }()
+
 	r, err := zip.OpenReader(fs.path)
 	if err != nil {
 		return err
@@ -330,41 +465,71 @@ func (fs *FS) Unzip(dst string) error {
 		}
 	}
 
+	total, err := unzipSize(r.File, opts.MaxSize)
+	if err != nil {
+		return err
+	}
+	t := newTracker(ctx, "unzip", total, opts.Progress)
+
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+	}()
+
 	for _, f := range r.File {
 		if f.FileInfo().IsDir() {
 			continue
 		}
-		if err := fs.unzipFile(f, dstFs); err != nil {
+		t.file(f.Name)
+		path, err := fs.unzipFile(f, dstFs, opts.Password, t)
+		if err != nil {
 			return err
 		}
+		written = append(written, path)
 	}
 
+	t.finish()
 	return nil
 }
 
-// unzip file
-func (fs *FS) unzipFile(f *zip.File, dstFs *FS) error {
-	// nolint gosec // ignore G305: File traversal when extracting zip archive
-	dstPath := filepath.Join(dstFs.path, f.Name)
+// unzip file, a partially written file is removed
+func (fs *FS) unzipFile(f *zip.File, dstFs *FS, password string, t *tracker) (string, error) {
+	if err := t.err(); err != nil {
+		return "", err
+	}
+
+	dstPath, err := safeJoin(dstFs.path, f.Name)
+	if err != nil {
+		return "", err
+	}
 	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
-		return err
+		return "", err
 	}
 
-	file, err := f.Open()
+	file, err := openZipFile(f, password)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer file.Close()
 
 	destinationFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer destinationFile.Close()
 
 	// nolint G110 // ignore G110: Potential DoS vulnerability via decompression bomb
-	_, err = io.Copy(destinationFile, file)
+	_, err = io.Copy(destinationFile, t.reader(file))
+	if err == nil {
+		err = destinationFile.Close()
+	}
 	if err != nil {
-		return err
+		os.Remove(dstPath)
+		return "", err
 	}
-	return nil
+	return dstPath, nil
 }
@@ -0,0 +1,96 @@
+package fsx
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+type ignoreRule struct {
+	base     string // slash separated directory of the .gitignore, "" for the root
+	segments []string
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// gitignore holds the rules of all .gitignore files seen while walking a tree, later rules win.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of the .gitignore in dir, base is dir relative to the walk root.
+func (g *gitignore) load(dir, base string) error {
+	f, err := os.Open(dir + string(os.PathSeparator) + ".gitignore")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || line[0] == '#' {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	switch {
+	case line[0] == '!':
+		rule.negate, line = true, line[1:]
+	case line[0] == '\\':
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	// a slash at the beginning or in the middle anchors the pattern to the .gitignore directory
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// ignored reports whether the slash separated path rel is ignored.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.negate != ignored || (rule.dirOnly && !isDir) {
+			continue
+		}
+
+		name := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			name = rel[len(rule.base)+1:]
+		}
+
+		var match bool
+		if rule.anchored {
+			match = matchSegments(rule.segments, strings.Split(name, "/"))
+		} else {
+			match, _ = path.Match(rule.segments[0], path.Base(name))
+		}
+		if match {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
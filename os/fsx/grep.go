@@ -0,0 +1,250 @@
+package fsx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// GrepOptions configures Grep.
+type GrepOptions struct {
+	// IgnoreCase matches case-insensitively.
+	IgnoreCase bool
+	// Before and After are the number of context lines returned with every match.
+	Before int
+	After  int
+	// MaxCount stops searching a file after that many matching lines, 0 means no limit.
+	MaxCount int
+	// Include limits the search to files matching any of the globs, Exclude skips files and directories.
+	Include []string
+	Exclude []string
+	// Gitignore skips files ignored by the .gitignore files of the tree.
+	Gitignore bool
+	// Decompress searches the content of gzip and bzip2 compressed files, and of zlib compressed files
+	// named *.zz. Files failing to decompress are searched as they are.
+	Decompress bool
+	// MaxSize skips files larger than that, 0 means no limit.
+	MaxSize units.Base2Bytes
+	// Concurrency is the number of files searched at once, defaults to the number of CPUs.
+	Concurrency int
+}
+
+// GrepMatch is a matching line, Line and Column are 1-based. A file that could not be searched is
+// reported with Err set and no line.
+type GrepMatch struct {
+	Path   string
+	Line   int
+	Column int
+	Text   string
+	Before []string
+	After  []string
+	Err    error
+}
+
+// Grep searches the contents of the files of the tree for pattern, a regexp. Binary files are
+// skipped. Matches are sent on the returned channel as they are found, in file order within a
+// file, and the channel is closed once the search is done or ctx is cancelled.
+func (fs *FS) Grep(ctx context.Context, pattern string, opts GrepOptions) (<-chan GrepMatch, error) {
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, wrapErr("grep", fs.path, "", err)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	out := make(chan GrepMatch)
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				path := filepath.Join(fs.path, rel)
+				if err := grepFile(ctx, path, filepath.ToSlash(rel), re, opts, out); err != nil {
+					if !send(ctx, out, GrepMatch{Path: filepath.ToSlash(rel), Err: wrapErr("grep", path, "", err)}) {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		err := fs.walkGrep(ctx, opts, jobs)
+		close(jobs)
+		wg.Wait()
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			send(ctx, out, GrepMatch{Err: wrapErr("grep", fs.path, "", err)})
+		}
+	}()
+	return out, nil
+}
+
+func send(ctx context.Context, out chan<- GrepMatch, m GrepMatch) bool {
+	select {
+	case out <- m:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (fs *FS) walkGrep(ctx context.Context, opts GrepOptions, jobs chan<- string) error {
+	ignore := &gitignore{}
+	return filepath.Walk(fs.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(fs.path, path)
+		if err != nil {
+			return err
+		}
+		slashRel := filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if path != fs.path && (vcsDirs[info.Name()] || matchAnyGlob(opts.Exclude, slashRel) ||
+				(opts.Gitignore && ignore.ignored(slashRel, true))) {
+				return filepath.SkipDir
+			}
+			if !opts.Gitignore {
+				return nil
+			}
+			if slashRel == "." {
+				slashRel = ""
+			}
+			return ignore.load(path, slashRel)
+		}
+
+		switch {
+		case !info.Mode().IsRegular(),
+			opts.MaxSize > 0 && info.Size() > int64(opts.MaxSize),
+			matchAnyGlob(opts.Exclude, slashRel),
+			len(opts.Include) > 0 && !matchAnyGlob(opts.Include, slashRel),
+			opts.Gitignore && ignore.ignored(slashRel, false):
+			return nil
+		}
+
+		select {
+		case jobs <- rel:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func grepFile(ctx context.Context, path, rel string, re *regexp.Regexp, opts GrepOptions, out chan<- GrepMatch) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	if opts.Decompress {
+		header, _ := br.Peek(3)
+		format, err := DetectCompression(header)
+		// the zlib header check passes for plain text like "x = 1", trust it only with the extension
+		if err == nil && (format != FormatZlib || strings.EqualFold(filepath.Ext(path), format.Ext())) {
+			if zr, _, err := NewDecompressReader(br); err == nil {
+				defer zr.Close()
+				br = bufio.NewReader(zr)
+			} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			} else {
+				// not compressed after all, search the raw bytes
+				br = bufio.NewReader(f)
+			}
+		}
+	}
+
+	if sniff, _ := br.Peek(binarySniffLen); isBinary(sniff) {
+		return nil
+	}
+
+	var (
+		before  []string
+		pending []*GrepMatch // matches still collecting after context
+		count   int
+		lineNo  int
+	)
+	flush := func(all bool) bool {
+		for len(pending) > 0 && (all || len(pending[0].After) >= opts.After) {
+			if !send(ctx, out, *pending[0]) {
+				return false
+			}
+			pending = pending[1:]
+		}
+		return true
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if line == "" && err != nil {
+			break
+		}
+		lineNo++
+		line = strings.TrimRight(line, "\r\n")
+
+		for _, m := range pending {
+			if len(m.After) < opts.After {
+				m.After = append(m.After, line)
+			}
+		}
+		if !flush(false) {
+			return nil
+		}
+
+		if opts.MaxCount <= 0 || count < opts.MaxCount {
+			if loc := re.FindStringIndex(line); loc != nil {
+				count++
+				m := &GrepMatch{Path: rel, Line: lineNo, Column: loc[0] + 1, Text: line}
+				if len(before) > 0 {
+					m.Before = append([]string(nil), before...)
+				}
+				pending = append(pending, m)
+				if !flush(false) {
+					return nil
+				}
+			}
+		} else if len(pending) == 0 {
+			break
+		}
+
+		if opts.Before > 0 {
+			before = append(before, line)
+			if len(before) > opts.Before {
+				before = before[1:]
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	flush(true)
+	return nil
+}
@@ -0,0 +1,105 @@
+package fsx
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Grep(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore":         "build/\n*.log\n!keep.log\n",
+		"main.go":            "package main\n\n// TODO: one\nfunc main() {}\n// todo: two\n",
+		"build/out.go":       "// TODO: ignored dir\n",
+		"debug.log":          "TODO ignored file\n",
+		"keep.log":           "TODO negated\n",
+		"sub/.gitignore":     "/local.txt\n",
+		"sub/local.txt":      "TODO anchored\n",
+		"sub/deep/local.txt": "TODO not anchored here\n",
+		"bin/app":            "TODO\x00binary",
+		"init.el":            "(require 'cl)\n; TODO passes the zlib header check\n",
+		"fake.zz":            "x = 1\n# TODO not zlib after all\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	f, err := os.Create(filepath.Join(dir, "old.txt.gz"))
+	assert.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, _ = zw.Write([]byte("line\nTODO compressed\n"))
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	fs, err := New(dir)
+	assert.NoError(t, err)
+
+	collect := func(opts GrepOptions) []GrepMatch {
+		ch, err := fs.Grep(context.Background(), "todo", opts)
+		assert.NoError(t, err)
+		var matches []GrepMatch
+		for m := range ch {
+			assert.NoError(t, m.Err)
+			matches = append(matches, m)
+		}
+		sort.Slice(matches, func(i, j int) bool {
+			if matches[i].Path != matches[j].Path {
+				return matches[i].Path < matches[j].Path
+			}
+			return matches[i].Line < matches[j].Line
+		})
+		return matches
+	}
+	paths := func(matches []GrepMatch) string {
+		var p []string
+		for _, m := range matches {
+			p = append(p, m.Path)
+		}
+		return strings.Join(p, ",")
+	}
+
+	matches := collect(GrepOptions{IgnoreCase: true, Gitignore: true, Decompress: true})
+	assert.Equal(t, "fake.zz,init.el,keep.log,main.go,main.go,old.txt.gz,sub/deep/local.txt", paths(matches))
+
+	matches = collect(GrepOptions{IgnoreCase: true, Include: []string{"*.go"}, Exclude: []string{"build"}, Before: 1, After: 1})
+	assert.Equal(t, []GrepMatch{
+		{Path: "main.go", Line: 3, Column: 4, Text: "// TODO: one", Before: []string{""}, After: []string{"func main() {}"}},
+		{Path: "main.go", Line: 5, Column: 4, Text: "// todo: two", Before: []string{"func main() {}"}},
+	}, matches)
+
+	matches = collect(GrepOptions{IgnoreCase: true, Include: []string{"main.go"}, MaxCount: 1})
+	assert.Len(t, matches, 1)
+
+	matches = collect(GrepOptions{Include: []string{"*.go"}, Exclude: []string{"build"}})
+	assert.Len(t, matches, 1, "case sensitive")
+	assert.Equal(t, 5, matches[0].Line)
+
+	_, err = fs.Grep(context.Background(), "(", GrepOptions{})
+	assert.Error(t, err)
+}
+
+func TestFS_GrepCancel(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, strings.Repeat("f", i+1)), []byte(strings.Repeat("match\n", 100)), 0o644))
+	}
+	fs, err := New(dir)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := fs.Grep(ctx, "match", GrepOptions{})
+	assert.NoError(t, err)
+	<-ch
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,203 @@
+package fsx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// newAbs returns the FS of an already absolute path, it never fails unlike New.
+func newAbs(path string) *FS {
+	fs := &FS{path: filepath.Clean(path)}
+	fs.fileInfo, _ = fs.State()
+	return fs
+}
+
+// Join returns the FS of the path joined with elem.
+func (fs *FS) Join(elem ...string) *FS {
+	return newAbs(filepath.Join(append([]string{fs.path}, elem...)...))
+}
+
+// Parent returns the FS of the parent directory, the root is its own parent.
+func (fs *FS) Parent() *FS {
+	return newAbs(filepath.Dir(fs.path))
+}
+
+// Ancestors returns all parent directories, nearest first and the root last.
+func (fs *FS) Ancestors() []*FS {
+	var ancestors []*FS
+	for path := fs.path; ; {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return ancestors
+		}
+		ancestors = append(ancestors, newAbs(parent))
+		path = parent
+	}
+}
+
+// Children returns the entries of the directory sorted by name.
+func (fs *FS) Children() ([]*FS, error) {
+	entries, err := os.ReadDir(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*FS, 0, len(entries))
+	for _, entry := range entries {
+		children = append(children, fs.Join(entry.Name()))
+	}
+	return children, nil
+}
+
+// Rel returns the path relative to base, e.g. "../b/c".
+func (fs *FS) Rel(base *FS) (string, error) {
+	return filepath.Rel(base.path, fs.path)
+}
+
+// Stem returns the base name without its extension, "archive.tar.gz" gives "archive.tar".
+func (fs *FS) Stem() string {
+	return strings.TrimSuffix(fs.Base(), fs.Ext())
+}
+
+// WithExt returns the FS with the extension replaced, ext may omit the dot and "" removes it.
+func (fs *FS) WithExt(ext string) *FS {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return newAbs(strings.TrimSuffix(fs.path, fs.Ext()) + ext)
+}
+
+// TreeOptions configures Tree.
+type TreeOptions struct {
+	// Depth limits how deep directories are descended, 0 means no limit.
+	Depth int
+	// Sizes prints file sizes.
+	Sizes bool
+	// All includes hidden files and directories, those starting with a dot.
+	All bool
+	// Include lists only files matching any of the globs, directories are always listed.
+	Include []string
+	// Exclude leaves out files and directories matching any of the globs.
+	Exclude []string
+}
+
+// Tree renders the directory like tree(1):
+//
+//	app
+//	├── cmd
+//	│   └── main.go
+//	└── go.mod
+//
+//	1 directory, 2 files
+func (fs *FS) Tree(opts TreeOptions) (string, error) {
+	var b strings.Builder
+	if err := fs.WriteTree(&b, opts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// WriteTree writes the output of Tree to w.
+func (fs *FS) WriteTree(w io.Writer, opts TreeOptions) error {
+	t := &treeWriter{w: w, opts: opts, root: fs.path}
+	if _, err := fmt.Fprintln(w, fs.Base()); err != nil {
+		return err
+	}
+	if err := t.dir(fs.path, "", 1); err != nil {
+		return err
+	}
+
+	dirs, files := "directories", "files"
+	if t.dirs == 1 {
+		dirs = "directory"
+	}
+	if t.files == 1 {
+		files = "file"
+	}
+	_, err := fmt.Fprintf(w, "\n%d %s, %d %s\n", t.dirs, dirs, t.files, files)
+	return err
+}
+
+type treeWriter struct {
+	w     io.Writer
+	opts  TreeOptions
+	root  string
+	dirs  int
+	files int
+}
+
+func (t *treeWriter) dir(path, prefix string, depth int) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	shown := entries[:0]
+	for _, entry := range entries {
+		rel, err := filepath.Rel(t.root, filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case !t.opts.All && strings.HasPrefix(entry.Name(), "."),
+			matchAnyGlob(t.opts.Exclude, rel),
+			!entry.IsDir() && len(t.opts.Include) > 0 && !matchAnyGlob(t.opts.Include, rel):
+			continue
+		}
+		shown = append(shown, entry)
+	}
+
+	for i, entry := range shown {
+		branch, indent := "├── ", "│   "
+		if i == len(shown)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		line, err := t.line(path, entry)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(t.w, "%s%s%s\n", prefix, branch, line); err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			t.files++
+			continue
+		}
+		t.dirs++
+		if t.opts.Depth <= 0 || depth < t.opts.Depth {
+			if err := t.dir(filepath.Join(path, entry.Name()), prefix+indent, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *treeWriter) line(dir string, entry os.DirEntry) (string, error) {
+	name := entry.Name()
+	if entry.Type()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		name += " -> " + target
+	}
+	if !t.opts.Sizes || entry.IsDir() {
+		return name, nil
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[%s]  %s", units.Base2Bytes(info.Size()), name), nil
+}
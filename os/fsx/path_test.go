@@ -0,0 +1,81 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Navigation(t *testing.T) {
+	fs, err := New("/srv/app/releases/v1.2.tar.gz")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/srv/app/releases", fs.Parent().Path())
+	assert.Equal(t, "/srv/app/releases/v1.2/x", fs.Parent().Join("v1.2", "x").Path())
+	assert.Equal(t, "v1.2.tar", fs.Stem())
+	assert.Equal(t, "/srv/app/releases/v1.2.tar", fs.WithExt("").Path())
+	assert.Equal(t, "/srv/app/releases/v1.2.tar.zst", fs.WithExt("zst").Path())
+	assert.Equal(t, "/srv/app/releases/v1.2.tar.xz", fs.WithExt(".xz").Path())
+
+	var ancestors []string
+	for _, a := range fs.Ancestors() {
+		ancestors = append(ancestors, a.Path())
+	}
+	assert.Equal(t, []string{"/srv/app/releases", "/srv/app", "/srv", "/"}, ancestors)
+
+	base, err := New("/srv/app/config")
+	assert.NoError(t, err)
+	rel, err := fs.Rel(base)
+	assert.NoError(t, err)
+	assert.Equal(t, "../releases/v1.2.tar.gz", rel)
+}
+
+func TestFS_Tree(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	writeTestTree(t, dir, map[string]string{
+		"go.mod":              "module app\n",
+		"cmd/app/main.go":     "package main\n",
+		"internal/db/db.go":   "package db\n",
+		"internal/db/db.sql":  "select 1;\n",
+		".env":                "SECRET=1\n",
+		"docs/big/readme.txt": "docs\n",
+	})
+	assert.NoError(t, os.Symlink("go.mod", filepath.Join(dir, "link")))
+
+	fs, err := New(dir)
+	assert.NoError(t, err)
+
+	children, err := fs.Children()
+	assert.NoError(t, err)
+	assert.Len(t, children, 6)
+	assert.Equal(t, ".env", children[0].Base())
+
+	out, err := fs.Tree(TreeOptions{Sizes: true, Exclude: []string{"docs"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `app
+├── cmd
+│   └── app
+│       └── [13B]  main.go
+├── [11B]  go.mod
+├── internal
+│   └── db
+│       ├── [11B]  db.go
+│       └── [10B]  db.sql
+└── [6B]  link -> go.mod
+
+4 directories, 5 files
+`, out)
+
+	out, err = fs.Tree(TreeOptions{Depth: 1, All: true, Include: []string{".env"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `app
+├── .env
+├── cmd
+├── docs
+└── internal
+
+3 directories, 1 file
+`, out)
+}
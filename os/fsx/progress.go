@@ -0,0 +1,118 @@
+package fsx
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// Progress is reported by the context aware variants of long-running operations, such as
+// ZipContext or Sha256Context.
+type Progress struct {
+	Op      string // e.g. "zip", "sha256"
+	File    string // file currently processed, for archives the entry name
+	Done    units.Base2Bytes
+	Total   units.Base2Bytes // 0 when unknown
+	Rate    units.Base2Bytes // bytes per second
+	Elapsed time.Duration
+}
+
+// Percent returns the completed share from 0 to 100, or -1 when the total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Done) * 100 / float64(p.Total)
+}
+
+// ProgressFunc receives progress events, at most every 100ms and once at the end.
+type ProgressFunc func(Progress)
+
+// progressInterval throttles progress events, a constant so concurrent operations cannot change
+// each other's rate.
+const progressInterval = 100 * time.Millisecond
+
+// tracker counts the bytes of an operation and checks for cancellation, a nil tracker does nothing.
+type tracker struct {
+	ctx   context.Context
+	fn    ProgressFunc
+	p     Progress
+	start time.Time
+	last  time.Time
+}
+
+func newTracker(ctx context.Context, op string, total int64, fn ProgressFunc) *tracker {
+	now := time.Now()
+	return &tracker{ctx: ctx, fn: fn, p: Progress{Op: op, Total: units.Base2Bytes(total)}, start: now, last: now}
+}
+
+func (t *tracker) err() error {
+	if t == nil {
+		return nil
+	}
+	return t.ctx.Err()
+}
+
+func (t *tracker) file(name string) {
+	if t == nil {
+		return
+	}
+	t.p.File = name
+	t.emit(false)
+}
+
+func (t *tracker) add(n int64) {
+	if t == nil {
+		return
+	}
+	t.p.Done += units.Base2Bytes(n)
+	t.emit(false)
+}
+
+// finish sends the final event.
+func (t *tracker) finish() {
+	if t == nil {
+		return
+	}
+	t.emit(true)
+}
+
+func (t *tracker) emit(force bool) {
+	if t.fn == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(t.last) < progressInterval {
+		return
+	}
+	t.last = now
+	t.p.Elapsed = now.Sub(t.start)
+	if secs := t.p.Elapsed.Seconds(); secs > 0 {
+		t.p.Rate = units.Base2Bytes(float64(t.p.Done) / secs)
+	}
+	t.fn(t.p)
+}
+
+// reader counts what is read from r and stops with the context error once cancelled.
+func (t *tracker) reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &trackReader{t: t, r: r}
+}
+
+type trackReader struct {
+	t *tracker
+	r io.Reader
+}
+
+func (r *trackReader) Read(p []byte) (int, error) {
+	if err := r.t.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	r.t.add(int64(n))
+	return n, err
+}
@@ -0,0 +1,141 @@
+package fsx
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Sha256Context(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	assert.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), 1<<20), 0o644))
+
+	fs, err := New(path)
+	assert.NoError(t, err)
+
+	var events []Progress
+	sum, err := fs.Sha256Context(context.Background(), func(p Progress) { events = append(events, p) })
+	assert.NoError(t, err)
+	want, _ := fs.Sha256()
+	assert.Equal(t, want, sum)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "sha256", last.Op)
+	assert.Equal(t, path, last.File)
+	assert.EqualValues(t, 1<<20, last.Done)
+	assert.EqualValues(t, 100, last.Percent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fs.Sha256Context(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFS_ZipContextCancel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTestTree(t, src, map[string]string{
+		"a.txt":     "alpha\n",
+		"sub/b.txt": "beta\n",
+	})
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+
+	archive := filepath.Join(dir, "src.zip")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, fs.ZipContext(ctx, archive, nil), context.Canceled)
+	assert.NoFileExists(t, archive)
+
+	var last Progress
+	assert.NoError(t, fs.ZipContext(context.Background(), archive, func(p Progress) { last = p }))
+	assert.Equal(t, "zip", last.Op)
+	assert.EqualValues(t, 11, last.Done)
+	assert.EqualValues(t, 11, last.Total)
+
+	zfs, err := New(archive)
+	assert.NoError(t, err)
+	out := filepath.Join(dir, "out")
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, zfs.UnzipContext(ctx, out, nil), context.Canceled)
+	assert.NoFileExists(t, filepath.Join(out, "a.txt"))
+
+	assert.NoError(t, zfs.UnzipContext(context.Background(), out, func(p Progress) { last = p }))
+	assert.Equal(t, "unzip", last.Op)
+	assert.EqualValues(t, 11, last.Done)
+	assert.FileExists(t, filepath.Join(out, "sub", "b.txt"))
+}
+
+func TestContextVariants(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTestTree(t, src, map[string]string{
+		"a.txt":     "alpha\n",
+		"sub/b.txt": "beta\n",
+	})
+	fs, err := New(src)
+	assert.NoError(t, err)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var last Progress
+	record := func(p Progress) { last = p }
+
+	// Extract
+	dst, err := New(filepath.Join(dir, "extracted"))
+	assert.NoError(t, err)
+	_, err = ExtractContext(cancelled, os.DirFS(src), ".", dst, ExtractOptions{}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = ExtractContext(context.Background(), os.DirFS(src), ".", dst, ExtractOptions{}, record)
+	assert.NoError(t, err)
+	assert.Equal(t, "extract", last.Op)
+	assert.EqualValues(t, 11, last.Done)
+
+	// Snapshot and restore
+	repo := fs.SnapshotRepo(filepath.Join(dir, "snapshots"))
+	_, err = repo.CreateContext(cancelled, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	snapshot, err := repo.CreateContext(context.Background(), record)
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot", last.Op)
+	assert.EqualValues(t, 100, last.Percent())
+
+	restored := filepath.Join(dir, "restored")
+	assert.ErrorIs(t, snapshot.RestoreContext(cancelled, restored, nil), context.Canceled)
+	assert.NoDirExists(t, restored)
+	assert.NoError(t, snapshot.RestoreContext(context.Background(), restored, record))
+	assert.EqualValues(t, 11, last.Total)
+
+	// VerifyChecksums
+	a, _ := New(filepath.Join(src, "a.txt"))
+	b, _ := New(filepath.Join(src, "sub", "b.txt"))
+	sums, err := New(filepath.Join(src, "SHA256SUMS"))
+	assert.NoError(t, err)
+	assert.NoError(t, sums.WriteChecksums(ChecksumSHA256, a, b))
+	_, err = sums.VerifyChecksumsContext(cancelled, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	report, err := sums.VerifyChecksumsContext(context.Background(), record)
+	assert.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, "verify", last.Op)
+	assert.EqualValues(t, 11, last.Done)
+
+	// Replace
+	pattern := regexp.MustCompile(`a`)
+	_, err = fs.ReplaceContext(cancelled, pattern, "A", ReplaceOptions{Include: []string{"*.txt"}}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	content, _ := os.ReadFile(filepath.Join(src, "a.txt"))
+	assert.Equal(t, "alpha\n", string(content))
+	summary, err := fs.ReplaceContext(context.Background(), pattern, "A", ReplaceOptions{Include: []string{"*.txt"}}, record)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, summary.Matches)
+	assert.Equal(t, "replace", last.Op)
+	assert.EqualValues(t, 11, last.Done)
+}
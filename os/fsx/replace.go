@@ -0,0 +1,229 @@
+package fsx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rogeecn/tl/stringx"
+)
+
+// vcsDirs are never walked by tree operations like Replace and Grep.
+var vcsDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// ReplaceOptions configures Replace.
+type ReplaceOptions struct {
+	// Include limits the files to those matching any of the globs, all files when empty.
+	Include []string
+	// Exclude skips files and directories matching any of the globs.
+	Exclude []string
+	// Backup keeps the original content as <file>.bak, such files of other listed files are skipped.
+	Backup bool
+	// DryRun reports the changes without writing anything.
+	DryRun bool
+	// Diff fills ReplaceFile.Diff with a unified diff of every changed file.
+	Diff bool
+	// Concurrency is the number of files processed at once, defaults to the number of CPUs.
+	Concurrency int
+}
+
+// ReplaceFile is a file changed by Replace, Path is relative to the tree root.
+type ReplaceFile struct {
+	Path    string
+	Matches int
+	Diff    string
+}
+
+// ReplaceSummary is the result of Replace.
+type ReplaceSummary struct {
+	Scanned int
+	Files   []ReplaceFile
+	Matches int
+}
+
+const backupSuffix = ".bak"
+
+// Replace replaces every match of pattern with repl in the text files of the tree, like sed -i.
+// Replacement uses the semantics of stringx.String.WithRegex(pattern).ReplaceAllString(repl), so
+// $1 and ${name} expand to submatches. Binary files are skipped and changed files are written
+// atomically.
+func (fs *FS) Replace(pattern *regexp.Regexp, repl string, opts ReplaceOptions) (*ReplaceSummary, error) {
+	return fs.ReplaceContext(context.Background(), pattern, repl, opts, nil)
+}
+
+// ReplaceContext is Replace with cancellation and progress events counting the bytes of the scanned
+// files. Files already written when it is cancelled stay changed and are listed in the summary.
+func (fs *FS) ReplaceContext(ctx context.Context, pattern *regexp.Regexp, repl string, opts ReplaceOptions, progress ProgressFunc) (_ *ReplaceSummary, err error) {
+	defer func() { err = wrapErr("replace", fs.path, "", err) }()
+
+	files, err := fs.walkFiles(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Backup {
+		files = skipBackups(files)
+	}
+
+	var total int64
+	sizes := make(map[string]int64, len(files))
+	for _, rel := range files {
+		if info, err := os.Stat(filepath.Join(fs.path, rel)); err == nil {
+			sizes[rel] = info.Size()
+			total += info.Size()
+		}
+	}
+	t := newTracker(ctx, "replace", total, progress)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		jobs     = make(chan string)
+		summary  = &ReplaceSummary{Scanned: len(files)}
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				file, err := replaceFile(filepath.Join(fs.path, rel), rel, pattern, repl, opts)
+
+				// the tracker is not safe for concurrent use
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if file != nil {
+					summary.Files = append(summary.Files, *file)
+					summary.Matches += file.Matches
+				}
+				t.file(rel)
+				t.add(sizes[rel])
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, rel := range files {
+		select {
+		case jobs <- rel:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(summary.Files, func(i, j int) bool { return summary.Files[i].Path < summary.Files[j].Path })
+	if err := t.err(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if firstErr == nil {
+		t.finish()
+	}
+	return summary, firstErr
+}
+
+func replaceFile(path, rel string, pattern *regexp.Regexp, repl string, opts ReplaceOptions) (*ReplaceFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || isBinary(content) {
+		return nil, err
+	}
+
+	s := stringx.New(string(content)).WithRegex(pattern)
+	matches := len(s.FindAllStringIndex())
+	if matches == 0 {
+		return nil, nil
+	}
+	replaced := s.ReplaceAllString(repl).String()
+	if replaced == string(content) {
+		return nil, nil
+	}
+
+	file := &ReplaceFile{Path: filepath.ToSlash(rel), Matches: matches}
+	if opts.Diff {
+		file.Diff = unifiedDiff("a/"+file.Path, "b/"+file.Path, string(content), replaced)
+	}
+	if opts.DryRun {
+		return file, nil
+	}
+
+	if opts.Backup {
+		if err := writeFileAtomic(path+backupSuffix, strings.NewReader(string(content)), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeFileAtomic(path, strings.NewReader(replaced), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// walkFiles lists the regular files of the tree relative to its root, filtered by include and
+// exclude globs. Version control directories are skipped.
+func (fs *FS) walkFiles(include, exclude []string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(fs.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == fs.path {
+			return nil
+		}
+
+		rel, err := filepath.Rel(fs.path, path)
+		if err != nil {
+			return err
+		}
+		slashRel := filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if vcsDirs[info.Name()] || matchAnyGlob(exclude, slashRel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if matchAnyGlob(exclude, slashRel) || (len(include) > 0 && !matchAnyGlob(include, slashRel)) {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+// skipBackups drops the files the backups of other listed files would overwrite.
+func skipBackups(files []string) []string {
+	listed := make(map[string]bool, len(files))
+	for _, file := range files {
+		listed[file] = true
+	}
+
+	kept := files[:0]
+	for _, file := range files {
+		if original, ok := strings.CutSuffix(file, backupSuffix); !ok || !listed[original] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,94 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Replace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":         "package main\n\nimport \"github.com/old/lib\"\n\nfunc main() { lib.Run() }\n",
+		"pkg/a/a.go":      "package a\n\nimport \"github.com/old/lib/v2\"\n",
+		"pkg/a/a_test.go": "package a // github.com/old/lib\n",
+		"vendor/x/x.go":   "package x // github.com/old/lib\n",
+		"README.md":       "see github.com/old/lib\n",
+		"assets/logo.bin": "github.com/old/lib\x00\x01",
+		".git/config":     "url = github.com/old/lib\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	fs, err := New(dir)
+	assert.NoError(t, err)
+	pattern := regexp.MustCompile(`github\.com/old/(lib)`)
+	opts := ReplaceOptions{
+		Include: []string{"**/*.go"},
+		Exclude: []string{"vendor", "*_test.go"},
+		Diff:    true,
+		DryRun:  true,
+	}
+
+	summary, err := fs.Replace(pattern, "github.com/new/$1", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, summary.Scanned)
+	assert.Equal(t, 2, summary.Matches)
+	assert.Len(t, summary.Files, 2)
+	assert.Equal(t, "main.go", summary.Files[0].Path)
+	assert.Equal(t, "--- a/main.go\n+++ b/main.go\n@@ -1,5 +1,5 @@\n package main\n \n-import \"github.com/old/lib\"\n+import \"github.com/new/lib\"\n \n func main() { lib.Run() }\n", summary.Files[0].Diff)
+
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, files["main.go"], string(content), "dry run must not write")
+
+	opts.DryRun, opts.Backup = false, true
+	_, err = fs.Replace(pattern, "github.com/new/$1", opts)
+	assert.NoError(t, err)
+
+	content, err = os.ReadFile(filepath.Join(dir, "pkg", "a", "a.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package a\n\nimport \"github.com/new/lib/v2\"\n", string(content))
+	backup, err := os.ReadFile(filepath.Join(dir, "pkg", "a", "a.go.bak"))
+	assert.NoError(t, err)
+	assert.Equal(t, files["pkg/a/a.go"], string(backup))
+
+	for _, name := range []string{"vendor/x/x.go", "pkg/a/a_test.go", ".git/config"} {
+		content, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		assert.NoError(t, err)
+		assert.Equal(t, files[name], string(content), name)
+	}
+
+	// binary files are skipped even when included
+	summary, err = fs.Replace(pattern, "x", ReplaceOptions{Include: []string{"*.bin", "*.md"}})
+	assert.NoError(t, err)
+	assert.Len(t, summary.Files, 1)
+	assert.Equal(t, "README.md", summary.Files[0].Path)
+
+	// .bak files are only skipped when this run writes a backup over them
+	older := regexp.MustCompile(`old`)
+	summary, err = fs.Replace(older, "older", ReplaceOptions{Include: []string{"pkg/a/a.go*"}, DryRun: true})
+	assert.NoError(t, err)
+	assert.Len(t, summary.Files, 1)
+	assert.Equal(t, filepath.Join("pkg", "a", "a.go.bak"), summary.Files[0].Path)
+	summary, err = fs.Replace(older, "older", ReplaceOptions{Include: []string{"pkg/a/a.go*"}, DryRun: true, Backup: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Scanned, "a.go.bak is where the backup of a.go goes")
+	assert.Len(t, summary.Files, 0)
+}
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, matchGlob("*.go", "a/b/c.go"))
+	assert.True(t, matchGlob("**/*.go", "c.go"))
+	assert.True(t, matchGlob("**/*.go", "a/b/c.go"))
+	assert.True(t, matchGlob("a/**/c.go", "a/c.go"))
+	assert.True(t, matchGlob("a/**", "a/b/c.go"))
+	assert.False(t, matchGlob("a/*.go", "a/b/c.go"))
+	assert.False(t, matchGlob("b/**/*.go", "a/b/c.go"))
+}
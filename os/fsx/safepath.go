@@ -0,0 +1,36 @@
+package fsx
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// safeJoin joins an archive or fs.FS entry name onto dir and rejects names escaping it,
+// such as "../etc/passwd" (zip slip). Leading slashes are dropped like unzip(1) does.
+func safeJoin(dir, name string) (string, error) {
+	name = filepath.FromSlash(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafePath)
+	}
+
+	path := filepath.Join(dir, name)
+	if !pathWithin(filepath.Clean(dir), path) {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafePath)
+	}
+	return path, nil
+}
+
+// validEntryName reports whether an archive entry name is relative and free of ".." segments.
+func validEntryName(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") || filepath.VolumeName(filepath.FromSlash(name)) != "" {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package fsx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rogeecn/tl/stringx"
+)
+
+// ScaffoldAction is what Scaffold did, or would do in dry-run, with a file.
+type ScaffoldAction string
+
+const (
+	ScaffoldCreate    ScaffoldAction = "create"
+	ScaffoldOverwrite ScaffoldAction = "overwrite"
+	ScaffoldSkip      ScaffoldAction = "skip"
+)
+
+// ScaffoldFile is one file of the rendered tree, Path is relative to the destination.
+type ScaffoldFile struct {
+	Path   string
+	Action ScaffoldAction
+}
+
+// ScaffoldOptions configures Scaffold.
+type ScaffoldOptions struct {
+	// Data is passed to every path and content template.
+	Data interface{}
+	// TemplateSuffix marks files whose content is rendered, it is removed from the output name.
+	// Other files are copied as is. Defaults to ".tmpl".
+	TemplateSuffix string
+	// DryRun only reports what would be written.
+	DryRun bool
+	// Overwrite is asked for every file that already exists, existing files are skipped when nil.
+	Overwrite func(path string) bool
+	// Skip is asked for every file that would be written, returning true leaves it out.
+	Skip func(path string) bool
+	// Funcs are added to the template functions, replacing defaults of the same name. The defaults
+	// are snake, screamingSnake, kebab, screamingKebab, camel, pascal, lower and upper.
+	Funcs template.FuncMap
+}
+
+// scaffoldFuncs are the default template functions of paths and contents, case conversions use stringx.
+var scaffoldFuncs = template.FuncMap{
+	"snake":          func(s string) string { return stringx.New(s).ToSnakeCase().String() },
+	"screamingSnake": func(s string) string { return stringx.New(s).ToScreamingSnakeCase().String() },
+	"kebab":          func(s string) string { return stringx.New(s).ToKebabCase().String() },
+	"screamingKebab": func(s string) string { return stringx.New(s).ToScreamingKebabCase().String() },
+	"camel":          func(s string) string { return stringx.New(s).ToLowerCamelCase().String() },
+	"pascal":         func(s string) string { return stringx.New(s).ToPascalCase().String() },
+	"lower":          func(s string) string { return stringx.New(s).ToLower().String() },
+	"upper":          func(s string) string { return stringx.New(s).ToUpper().String() },
+}
+
+// Scaffold renders the template directory into dst. Every path segment is a template, e.g.
+// "cmd/{{.Name | kebab}}/main.go.tmpl", and a segment rendering to an empty string leaves the file
+// or directory out, which makes "{{if .Docker}}Dockerfile{{end}}" conditional.
+func (fs *FS) Scaffold(dst string, opts ScaffoldOptions) (_ []ScaffoldFile, err error) {
+	defer func() { err = wrapErr("scaffold", fs.path, dst, err) }()
+
+	if opts.TemplateSuffix == "" {
+		opts.TemplateSuffix = ".tmpl"
+	}
+	dst, err = filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := template.FuncMap{}
+	for name, fn := range scaffoldFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range opts.Funcs {
+		funcs[name] = fn
+	}
+
+	var files []ScaffoldFile
+	err = filepath.Walk(fs.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == fs.path {
+			return err
+		}
+
+		rel, err := filepath.Rel(fs.path, path)
+		if err != nil {
+			return err
+		}
+		target, err := renderScaffoldPath(rel, opts.Data, funcs)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		render := strings.HasSuffix(target, opts.TemplateSuffix)
+		if render {
+			target = strings.TrimSuffix(target, opts.TemplateSuffix)
+		}
+		out := filepath.Join(dst, target)
+		if !pathWithin(dst, out) || out == dst {
+			return fmt.Errorf("%s: rendered path %q escapes the destination: %w", rel, target, ErrUnsafePath)
+		}
+
+		if info.IsDir() {
+			if opts.DryRun {
+				return nil
+			}
+			return os.MkdirAll(out, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file := ScaffoldFile{Path: target, Action: ScaffoldCreate}
+		if _, err := os.Lstat(out); err == nil {
+			file.Action = ScaffoldSkip
+			if opts.Overwrite != nil && opts.Overwrite(target) {
+				file.Action = ScaffoldOverwrite
+			}
+		}
+		if file.Action != ScaffoldSkip && opts.Skip != nil && opts.Skip(target) {
+			file.Action = ScaffoldSkip
+		}
+		files = append(files, file)
+
+		if opts.DryRun || file.Action == ScaffoldSkip {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(out), os.ModePerm); err != nil {
+			return err
+		}
+		return writeScaffoldFile(path, out, info.Mode().Perm(), render, opts.Data, funcs)
+	})
+	return files, err
+}
+
+// renderScaffoldPath renders every segment of rel, it returns "" when a segment renders empty.
+func renderScaffoldPath(rel string, data interface{}, funcs template.FuncMap) (string, error) {
+	segments := strings.Split(rel, string(filepath.Separator))
+	for i, segment := range segments {
+		if !strings.Contains(segment, "{{") {
+			continue
+		}
+		rendered, err := renderScaffold(rel, segment, data, funcs)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(rendered) == "" {
+			return "", nil
+		}
+		segments[i] = filepath.FromSlash(rendered)
+	}
+	return filepath.Join(segments...), nil
+}
+
+func renderScaffold(name, text string, data interface{}, funcs template.FuncMap) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func writeScaffoldFile(src, dst string, perm os.FileMode, render bool, data interface{}, funcs template.FuncMap) error {
+	if !render {
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		return writeFileAtomic(dst, in, perm)
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	rendered, err := renderScaffold(src, string(content), data, funcs)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, strings.NewReader(rendered), perm)
+}
@@ -0,0 +1,106 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_Scaffold(t *testing.T) {
+	root := t.TempDir()
+	tpl := filepath.Join(root, "template")
+	files := map[string]string{
+		"go.mod.tmpl":                          "module example.com/{{.Name | kebab}}\n",
+		"cmd/{{.Name | kebab}}/main.go.tmpl":   "package main // {{.Name | pascal}}\n",
+		"internal/{{.Name | snake}}/logo.png":  "{{not rendered}}",
+		"{{if .Docker}}Dockerfile{{end}}":      "FROM scratch\n",
+		"{{if .Docker}}deploy{{end}}/k8s.yaml": "kind: Deployment\n",
+		"README.md.tmpl":                       "# {{.Name}}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tpl, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	fs, err := New(tpl)
+	assert.NoError(t, err)
+	data := map[string]interface{}{"Name": "OrderService", "Docker": false}
+	dst := filepath.Join(root, "out")
+
+	planned, err := fs.Scaffold(dst, ScaffoldOptions{Data: data, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []ScaffoldFile{
+		{Path: "README.md", Action: ScaffoldCreate},
+		{Path: filepath.Join("cmd", "order-service", "main.go"), Action: ScaffoldCreate},
+		{Path: "go.mod", Action: ScaffoldCreate},
+		{Path: filepath.Join("internal", "order_service", "logo.png"), Action: ScaffoldCreate},
+	}, planned)
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+
+	written, err := fs.Scaffold(dst, ScaffoldOptions{Data: data})
+	assert.NoError(t, err)
+	assert.Equal(t, planned, written)
+
+	content, err := os.ReadFile(filepath.Join(dst, "cmd", "order-service", "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package main // OrderService\n", string(content))
+	content, err = os.ReadFile(filepath.Join(dst, "internal", "order_service", "logo.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{{not rendered}}", string(content))
+
+	// existing files are skipped unless the hook allows overwriting
+	assert.NoError(t, os.WriteFile(filepath.Join(dst, "README.md"), []byte("custom"), 0o644))
+	data["Docker"] = true
+	written, err = fs.Scaffold(dst, ScaffoldOptions{
+		Data:      data,
+		Overwrite: func(path string) bool { return path == "go.mod" },
+		Skip:      func(path string) bool { return path == "Dockerfile" },
+	})
+	assert.NoError(t, err)
+	actions := map[string]ScaffoldAction{}
+	for _, f := range written {
+		actions[f.Path] = f.Action
+	}
+	assert.Equal(t, ScaffoldSkip, actions["README.md"])
+	assert.Equal(t, ScaffoldOverwrite, actions["go.mod"])
+	assert.Equal(t, ScaffoldSkip, actions["Dockerfile"])
+	assert.Equal(t, ScaffoldCreate, actions[filepath.Join("deploy", "k8s.yaml")])
+
+	content, err = os.ReadFile(filepath.Join(dst, "README.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", string(content))
+
+	// custom functions are per call and replace defaults of the same name
+	funcs := ScaffoldOptions{
+		Data:      data,
+		Funcs:     map[string]interface{}{"upper": func(s string) string { return "UP " + s }},
+		Overwrite: func(string) bool { return true },
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(tpl, "NAME.tmpl"), []byte("{{.Name | upper}} {{.Name | kebab}}\n"), 0o644))
+	_, err = fs.Scaffold(dst, funcs)
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dst, "NAME"))
+	assert.NoError(t, err)
+	assert.Equal(t, "UP OrderService order-service\n", string(content))
+	_, err = fs.Scaffold(dst, ScaffoldOptions{Data: data, Overwrite: funcs.Overwrite})
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dst, "NAME"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ORDERSERVICE order-service\n", string(content))
+}
+
+func TestFS_ScaffoldEscape(t *testing.T) {
+	root := t.TempDir()
+	tpl := filepath.Join(root, "template", "{{.Name}}")
+	assert.NoError(t, os.MkdirAll(tpl, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tpl, "f"), nil, 0o644))
+
+	fs, err := New(filepath.Join(root, "template"))
+	assert.NoError(t, err)
+	_, err = fs.Scaffold(filepath.Join(root, "out"), ScaffoldOptions{Data: map[string]string{"Name": "../../x"}})
+	assert.ErrorContains(t, err, "escapes the destination")
+}
@@ -0,0 +1,290 @@
+package fsx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotLayout    = "20060102-150405"
+	snapshotTmpPrefix = ".tmp-"
+)
+
+// Snapshot is a point-in-time copy of a directory created by SnapshotRepo.Create.
+type Snapshot struct {
+	Name string
+	Time time.Time
+	Path string
+}
+
+// SnapshotRetention tells Prune how many snapshots to keep, one per hour, day and ISO week,
+// newest first. The newest snapshot is always kept.
+type SnapshotRetention struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// SnapshotRepo keeps timestamped snapshots of a source directory in dir, unchanged files are
+// hard-linked to the previous snapshot so every snapshot is complete but only changes use space.
+// dir must be on the same filesystem as the previous snapshots.
+type SnapshotRepo struct {
+	src *FS
+	dir string
+	now func() time.Time
+}
+
+// SnapshotRepo returns the snapshot repository of the directory kept in dir.
+func (fs *FS) SnapshotRepo(dir string) *SnapshotRepo {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &SnapshotRepo{src: fs, dir: dir, now: time.Now}
+}
+
+// List returns the snapshots in the repository, oldest first.
+func (r *SnapshotRepo) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		t, err := time.Parse(snapshotLayout, entry.Name())
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, &Snapshot{Name: entry.Name(), Time: t, Path: filepath.Join(r.dir, entry.Name())})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// Latest returns the newest snapshot, or nil when there is none.
+func (r *SnapshotRepo) Latest() (*Snapshot, error) {
+	snapshots, err := r.List()
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+// Create takes a new snapshot. Files with the same size, mode and mtime as in the latest snapshot
+// are hard-linked to it, everything else is copied. The snapshot is built under a temporary name
+// and only appears in List once it is complete.
+func (r *SnapshotRepo) Create() (*Snapshot, error) {
+	return r.CreateContext(context.Background(), nil)
+}
+
+// CreateContext is Create with cancellation and progress events, a cancelled snapshot is removed.
+func (r *SnapshotRepo) CreateContext(ctx context.Context, progress ProgressFunc) (*Snapshot, error) {
+	if !r.src.IsDir() {
+		return nil, wrapErr("snapshot", r.src.path, "", ErrNotDir)
+	}
+
+	prev, err := r.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now().UTC().Truncate(time.Second)
+	snapshot := &Snapshot{Name: now.Format(snapshotLayout), Time: now}
+	snapshot.Path = filepath.Join(r.dir, snapshot.Name)
+	if _, err := os.Lstat(snapshot.Path); err == nil {
+		return nil, wrapErr("snapshot", r.src.path, snapshot.Path, ErrExists)
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp := filepath.Join(r.dir, snapshotTmpPrefix+snapshot.Name)
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	total, err := treeSize(r.src.path)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(r.src.path, r.dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// the repository is skipped when it lives inside the source
+		if size, err := treeSize(r.dir); err == nil {
+			total -= size
+		}
+	}
+	t := newTracker(ctx, "snapshot", total, progress)
+	if err := buildSnapshot(r.src.path, tmp, r.dir, prev, t); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, snapshot.Path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+	t.finish()
+	return snapshot, nil
+}
+
+// buildSnapshot copies src to dst, linking unchanged files to prev when given. The skip directory,
+// usually the repository itself, is left out.
+func buildSnapshot(src, dst, skip string, prev *Snapshot, t *tracker) error {
+	type dirInfo struct {
+		path string
+		info os.FileInfo
+	}
+	var dirs []dirInfo
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := t.err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			// the snapshot repository may live inside the source
+			if path == skip {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, dirInfo{target, info})
+			return os.MkdirAll(target, 0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			t.file(rel)
+			defer t.add(info.Size())
+			if prev != nil && linkUnchanged(filepath.Join(prev.Path, rel), target, info) {
+				return nil
+			}
+			return copyFile(path, target, info)
+		default:
+			// sockets, pipes and devices are not snapshotted
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// directory modes and mtimes are set last, children would otherwise change the mtime
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].info.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dirs[i].path, dirs[i].info.ModTime(), dirs[i].info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func linkUnchanged(prev, target string, info os.FileInfo) bool {
+	prevInfo, err := os.Lstat(prev)
+	if err != nil || !prevInfo.Mode().IsRegular() {
+		return false
+	}
+	if prevInfo.Size() != info.Size() || prevInfo.Mode() != info.Mode() || !prevInfo.ModTime().Equal(info.ModTime()) {
+		return false
+	}
+	return os.Link(prev, target) == nil
+}
+
+// Prune removes the snapshots not kept by the retention rules and returns them.
+func (r *SnapshotRepo) Prune(keep SnapshotRetention) ([]*Snapshot, error) {
+	snapshots, err := r.List()
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+
+	kept := map[string]bool{snapshots[len(snapshots)-1].Name: true}
+	retain := func(n int, bucket func(t time.Time) string) {
+		seen := map[string]bool{}
+		for i := len(snapshots) - 1; i >= 0 && len(seen) < n; i-- {
+			key := bucket(snapshots[i].Time)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kept[snapshots[i].Name] = true
+		}
+	}
+	retain(keep.Hourly, func(t time.Time) string { return t.Format("2006010215") })
+	retain(keep.Daily, func(t time.Time) string { return t.Format("20060102") })
+	retain(keep.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	var removed []*Snapshot
+	for _, snapshot := range snapshots {
+		if kept[snapshot.Name] {
+			continue
+		}
+		if err := os.RemoveAll(snapshot.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, snapshot)
+	}
+	return removed, nil
+}
+
+// Get returns the snapshot with the given name.
+func (r *SnapshotRepo) Get(name string) (*Snapshot, error) {
+	snapshots, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot %s: %w", name, ErrNotExist)
+}
+
+// Restore copies the snapshot to dst, which must not exist. Files are copied rather than linked so
+// changes to the restored tree never leak into the snapshots.
+func (s *Snapshot) Restore(dst string) error {
+	return s.RestoreContext(context.Background(), dst, nil)
+}
+
+// RestoreContext is Restore with cancellation and progress events, a cancelled restore is removed.
+func (s *Snapshot) RestoreContext(ctx context.Context, dst string, progress ProgressFunc) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return wrapErr("restore", s.Path, dst, ErrExists)
+	}
+
+	total, err := treeSize(s.Path)
+	if err != nil {
+		return err
+	}
+	t := newTracker(ctx, "restore", total, progress)
+	if err := buildSnapshot(s.Path, dst, "", nil, t); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	t.finish()
+	return nil
+}
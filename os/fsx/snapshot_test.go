@@ -0,0 +1,99 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRepo(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "etc")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "conf.d"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "app.conf"), []byte("v1"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "conf.d", "db.conf"), []byte("db"), 0o600))
+	assert.NoError(t, os.Symlink("app.conf", filepath.Join(src, "current.conf")))
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+	repo := fs.SnapshotRepo(filepath.Join(root, "snapshots"))
+
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	first, err := repo.Create()
+	assert.NoError(t, err)
+	_, err = repo.Create()
+	assert.Error(t, err, "same timestamp")
+
+	// change one file, the other one must be shared with the first snapshot
+	mtime := time.Now().Add(time.Hour)
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "app.conf"), []byte("v2"), 0o644))
+	assert.NoError(t, os.Chtimes(filepath.Join(src, "app.conf"), mtime, mtime))
+	now = now.Add(time.Hour)
+	second, err := repo.Create()
+	assert.NoError(t, err)
+
+	a, err := os.Stat(filepath.Join(first.Path, "conf.d", "db.conf"))
+	assert.NoError(t, err)
+	b, err := os.Stat(filepath.Join(second.Path, "conf.d", "db.conf"))
+	assert.NoError(t, err)
+	assert.True(t, os.SameFile(a, b))
+
+	a, err = os.Stat(filepath.Join(first.Path, "app.conf"))
+	assert.NoError(t, err)
+	b, err = os.Stat(filepath.Join(second.Path, "app.conf"))
+	assert.NoError(t, err)
+	assert.False(t, os.SameFile(a, b))
+
+	link, err := os.Readlink(filepath.Join(second.Path, "current.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "app.conf", link)
+
+	list, err := repo.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Name, second.Name}, []string{list[0].Name, list[1].Name})
+
+	dst := filepath.Join(root, "restored")
+	assert.NoError(t, first.Restore(dst))
+	data, err := os.ReadFile(filepath.Join(dst, "app.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	info, err := os.Stat(filepath.Join(dst, "conf.d", "db.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	assert.False(t, os.SameFile(info, a))
+}
+
+func TestSnapshotRepo_Prune(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "data")
+	assert.NoError(t, os.MkdirAll(src, 0o755))
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+	repo := fs.SnapshotRepo(filepath.Join(root, "snapshots"))
+
+	// every 6 hours over 3 days
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		now := start.Add(time.Duration(i) * 6 * time.Hour)
+		repo.now = func() time.Time { return now }
+		_, err := repo.Create()
+		assert.NoError(t, err)
+	}
+
+	removed, err := repo.Prune(SnapshotRetention{Hourly: 2, Daily: 3})
+	assert.NoError(t, err)
+	assert.Len(t, removed, 8)
+
+	list, err := repo.List()
+	assert.NoError(t, err)
+	var names []string
+	for _, s := range list {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"20230501-180000", "20230502-180000", "20230503-120000", "20230503-180000"}, names)
+}
@@ -0,0 +1,268 @@
+package fsx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// SplitPart describes one chunk written by Split.
+type SplitPart struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// SplitManifest describes a file split into parts, it is stored next to the parts as JSON.
+type SplitManifest struct {
+	Name      string           `json:"name"`
+	Size      int64            `json:"size"`
+	ChunkSize units.Base2Bytes `json:"chunk_size"`
+	Sha256    string           `json:"sha256"`
+	Parts     []SplitPart      `json:"parts"`
+}
+
+// SplitManifestName returns the manifest file name Split writes for a file named base.
+func SplitManifestName(base string) string {
+	return base + ".manifest.json"
+}
+
+// Split writes the file as numbered parts of at most chunkSize bytes into dir, followed by
+// a manifest with per-part and whole-file sha256. Parts already present with the expected
+// content are kept, so an interrupted split can simply be run again.
+func (fs *FS) Split(chunkSize units.Base2Bytes, dir string) (*SplitManifest, error) {
+	return fs.SplitContext(context.Background(), chunkSize, dir, nil)
+}
+
+// SplitContext is Split with cancellation and progress events. Parts finished before a
+// cancellation are kept for the next run.
+func (fs *FS) SplitContext(ctx context.Context, chunkSize units.Base2Bytes, dir string, progress ProgressFunc) (_ *SplitManifest, err error) {
+	defer func() { err = wrapErr("split", fs.path, dir, err) }()
+
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size %s: %w", chunkSize, ErrInvalid)
+	}
+
+	src, err := fs.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	manifest := &SplitManifest{Name: fs.Base(), Size: info.Size(), ChunkSize: chunkSize}
+	whole := sha256.New()
+	t := newTracker(ctx, "split", info.Size(), progress)
+	for offset := int64(0); offset < info.Size(); offset += int64(chunkSize) {
+		size := int64(chunkSize)
+		if rest := info.Size() - offset; rest < size {
+			size = rest
+		}
+
+		part := SplitPart{
+			Name: fmt.Sprintf("%s.part%04d", manifest.Name, len(manifest.Parts)+1),
+			Size: size,
+		}
+		t.file(part.Name)
+		part.Sha256, err = writeSplitPart(io.NewSectionReader(src, offset, size), filepath.Join(dir, part.Name), whole, t)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Parts = append(manifest.Parts, part)
+	}
+	manifest.Sha256 = hex.EncodeToString(whole.Sum(nil))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, SplitManifestName(manifest.Name))
+	if err := writeFileAtomic(path, bytes.NewReader(data), 0o644); err != nil {
+		return nil, err
+	}
+	t.finish()
+	return manifest, nil
+}
+
+func writeSplitPart(section *io.SectionReader, path string, whole hash.Hash, t *tracker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h, whole), t.reader(section)); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	if existing, err := fileSha256(path); err == nil && existing == sum {
+		return sum, nil
+	}
+
+	if _, err := section.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return sum, writeFileAtomic(path, section, 0o644)
+}
+
+func fileSha256(path string) (string, error) {
+	f, err := New(path)
+	if err != nil {
+		return "", err
+	}
+	return f.Sha256()
+}
+
+// ReadSplitManifest reads a manifest written by Split.
+func ReadSplitManifest(path string) (*SplitManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest SplitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	var size int64
+	for _, part := range manifest.Parts {
+		if part.Name == "" || filepath.Base(part.Name) != part.Name || part.Name == ".." {
+			return nil, fmt.Errorf("part name %q: %w", part.Name, ErrUnsafePath)
+		}
+		size += part.Size
+	}
+	if size != manifest.Size {
+		return nil, fmt.Errorf("manifest parts add up to %d bytes, want %d", size, manifest.Size)
+	}
+	return &manifest, nil
+}
+
+// Join reassembles the parts listed in manifest into dst. Every part and the final digest are
+// verified before dst is atomically put in place. The output is assembled in dst+".partial",
+// verified parts found there from an interrupted join are kept and not copied again.
+func Join(manifest, dst string) error {
+	return JoinContext(context.Background(), manifest, dst, nil)
+}
+
+// JoinContext is Join with cancellation and progress events. A cancelled join keeps
+// dst+".partial" to be resumed.
+func JoinContext(ctx context.Context, manifest, dst string, progress ProgressFunc) (err error) {
+	defer func() { err = wrapErr("join", manifest, dst, err) }()
+
+	m, err := ReadSplitManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	partial := dst + ".partial"
+	out, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	info, err := out.Stat()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(manifest)
+	resuming := true
+	var offset int64
+	t := newTracker(ctx, "join", m.Size, progress)
+	for _, part := range m.Parts {
+		t.file(part.Name)
+		if resuming && info.Size() >= offset+part.Size {
+			sum, err := readerSha256(t.reader(io.NewSectionReader(out, offset, part.Size)))
+			if err != nil {
+				return err
+			}
+			if sum == part.Sha256 {
+				offset += part.Size
+				continue
+			}
+		}
+		resuming = false
+
+		if err := joinPart(out, offset, filepath.Join(dir, part.Name), part, t); err != nil {
+			return err
+		}
+		offset += part.Size
+	}
+	if err := out.Truncate(offset); err != nil {
+		return err
+	}
+
+	sum, err := readerSha256(io.NewSectionReader(out, 0, offset))
+	if err != nil {
+		return err
+	}
+	if sum != m.Sha256 {
+		return fmt.Errorf("%s: %w: sha256 %s, want %s", m.Name, ErrChecksumMismatch, sum, m.Sha256)
+	}
+
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partial, dst); err != nil {
+		return err
+	}
+	t.finish()
+	return nil
+}
+
+// joinPart copies one part to out at offset, a corrupt part is cut off again so a later
+// resume starts from a verified prefix.
+func joinPart(out *os.File, offset int64, path string, part SplitPart, t *tracker) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := out.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), t.reader(io.LimitReader(in, part.Size+1)))
+	if err != nil {
+		return err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if n != part.Size || sum != part.Sha256 {
+		if err := out.Truncate(offset); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %w: part is corrupt", part.Name, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+func readerSha256(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,57 @@
+package fsx
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rogeecn/tl/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_SplitJoin(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 10*1024+123)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	src := filepath.Join(dir, "dump.sql")
+	assert.NoError(t, os.WriteFile(src, data, 0o644))
+
+	fs, err := New(src)
+	assert.NoError(t, err)
+	manifest, err := fs.Split(4*units.KiB, filepath.Join(dir, "parts"))
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Parts, 3)
+	assert.Equal(t, int64(len(data)), manifest.Size)
+
+	manifestPath := filepath.Join(dir, "parts", SplitManifestName("dump.sql"))
+	dst := filepath.Join(dir, "out.sql")
+	assert.NoError(t, Join(manifestPath, dst))
+
+	got, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got))
+
+	// resume from a partial output holding the first part and garbage after it
+	partial := append(append([]byte{}, data[:4096]...), []byte("garbage")...)
+	assert.NoError(t, os.WriteFile(dst+".partial", partial, 0o644))
+	assert.NoError(t, os.Remove(dst))
+	assert.NoError(t, Join(manifestPath, dst))
+	got, err = os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got))
+
+	// a corrupt part is rejected and nothing is produced
+	assert.NoError(t, os.Remove(dst))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "parts", manifest.Parts[1].Name), make([]byte, 4096), 0o644))
+	assert.Error(t, Join(manifestPath, dst))
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+
+	// splitting again repairs the part
+	_, err = fs.Split(4*units.KiB, filepath.Join(dir, "parts"))
+	assert.NoError(t, err)
+	assert.NoError(t, Join(manifestPath, dst))
+}
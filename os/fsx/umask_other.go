@@ -0,0 +1,7 @@
+//go:build !unix
+
+package fsx
+
+func umask() uint32 {
+	return 0o022
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package fsx
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// startUmask is the umask at package init. Reading it with syscall.Umask means setting it, which
+// would hand files created meanwhile by other goroutines a zero umask, so it is only done once.
+var startUmask = func() uint32 {
+	if mask, ok := procUmask(); ok {
+		return mask
+	}
+	mask := syscall.Umask(0)
+	syscall.Umask(mask)
+	return uint32(mask)
+}()
+
+// umask returns the process umask, read from /proc on Linux and as it was at package init elsewhere.
+func umask() uint32 {
+	if mask, ok := procUmask(); ok {
+		return mask
+	}
+	return startUmask
+}
+
+// procUmask reads the "Umask:" line of /proc/self/status, Linux 4.7 and later.
+func procUmask() (uint32, bool) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Umask:"); ok {
+			mask, err := strconv.ParseUint(strings.TrimSpace(value), 8, 32)
+			return uint32(mask), err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package fsx
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUmask(t *testing.T) {
+	old := syscall.Umask(0o027)
+	defer syscall.Umask(old)
+
+	if _, ok := procUmask(); ok {
+		assert.Equal(t, uint32(0o027), umask())
+	} else {
+		assert.Equal(t, startUmask, umask())
+	}
+}
@@ -0,0 +1,163 @@
+package fsx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rogeecn/tl/units"
+)
+
+// Volume describes the filesystem a path lives on.
+type Volume struct {
+	MountPoint   string
+	Root         string // root of the mount within the filesystem, "/" unless a bind mount
+	Device       string // mount source, e.g. /dev/sda1
+	DeviceID     string // major:minor
+	FSType       string
+	Options      []string // per mount options, e.g. rw, noatime
+	SuperOptions []string // per filesystem options
+
+	Total     units.Base2Bytes
+	Free      units.Base2Bytes
+	Available units.Base2Bytes // free space available to unprivileged users
+
+	Inodes     uint64
+	InodesFree uint64
+}
+
+// HasOption reports whether the mount has the option, e.g. "ro".
+func (v *Volume) HasOption(option string) bool {
+	for _, o := range v.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Volume returns the mount point, filesystem and space usage of the path. Paths that do not
+// exist yet are resolved through their nearest existing parent, so a destination can be checked
+// before it is created.
+func (fs *FS) Volume() (_ *Volume, err error) {
+	defer func() { err = wrapErr("volume", fs.path, "", err) }()
+
+	path, err := existingAncestor(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	vol, err := volumeOf(path)
+	if err != nil {
+		return nil, err
+	}
+	if vol == nil {
+		return nil, fmt.Errorf("mount point: %w", ErrNotExist)
+	}
+	return vol, nil
+}
+
+// HasSpaceFor reports whether size bytes fit into the space available to unprivileged users.
+func (fs *FS) HasSpaceFor(size units.Base2Bytes) (bool, error) {
+	vol, err := fs.Volume()
+	if err != nil {
+		return false, err
+	}
+	return vol.Available >= size, nil
+}
+
+func existingAncestor(path string) (string, error) {
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		path = parent
+	}
+}
+
+// findMount returns the deepest mount containing path, later entries win as they mount over earlier ones.
+func findMount(mounts []Volume, path string) *Volume {
+	var found *Volume
+	for i := range mounts {
+		m := &mounts[i]
+		if !pathWithin(m.MountPoint, path) {
+			continue
+		}
+		if found == nil || len(m.MountPoint) >= len(found.MountPoint) {
+			found = m
+		}
+	}
+	return found
+}
+
+func pathWithin(dir, path string) bool {
+	if dir == "/" || dir == path {
+		return true
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// parseMountinfo parses proc(5) mountinfo lines:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseMountinfo(r io.Reader) ([]Volume, error) {
+	var mounts []Volume
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 10 || sep < 0 || len(fields) < sep+4 {
+			return nil, fmt.Errorf("invalid mountinfo line: %q", scanner.Text())
+		}
+
+		mounts = append(mounts, Volume{
+			DeviceID:     fields[2],
+			Root:         unescapeMountinfo(fields[3]),
+			MountPoint:   unescapeMountinfo(fields[4]),
+			Options:      strings.Split(fields[5], ","),
+			FSType:       fields[sep+1],
+			Device:       unescapeMountinfo(fields[sep+2]),
+			SuperOptions: strings.Split(fields[sep+3], ","),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// the kernel escapes space, tab, newline and backslash as \ooo octal
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
@@ -0,0 +1,50 @@
+package fsx
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/rogeecn/tl/units"
+)
+
+const mountinfoFile = "/proc/self/mountinfo"
+
+// volumeOf returns the mount holding path with its space usage, nil when no mount holds it.
+func volumeOf(path string) (*Volume, error) {
+	f, err := os.Open(mountinfoFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mounts, err := parseMountinfo(f)
+	if err != nil {
+		return nil, err
+	}
+	vol := findMount(mounts, path)
+	if vol == nil {
+		return nil, nil
+	}
+	if err := statVolume(path, vol); err != nil {
+		return nil, err
+	}
+	return vol, nil
+}
+
+func statVolume(path string, vol *Volume) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return err
+	}
+
+	bsize := int64(st.Frsize)
+	if bsize == 0 {
+		bsize = int64(st.Bsize)
+	}
+	vol.Total = units.Base2Bytes(int64(st.Blocks) * bsize)
+	vol.Free = units.Base2Bytes(int64(st.Bfree) * bsize)
+	vol.Available = units.Base2Bytes(int64(st.Bavail) * bsize)
+	vol.Inodes = st.Files
+	vol.InodesFree = st.Ffree
+	return nil
+}
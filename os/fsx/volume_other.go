@@ -0,0 +1,12 @@
+//go:build !linux
+
+package fsx
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func volumeOf(string) (*Volume, error) {
+	return nil, fmt.Errorf("volume information on %s: %w", runtime.GOOS, ErrUnsupported)
+}
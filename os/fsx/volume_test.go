@@ -0,0 +1,54 @@
+package fsx
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rogeecn/tl/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMountinfo(t *testing.T) {
+	input := strings.Join([]string{
+		"22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro",
+		"36 22 8:2 / /data rw,noatime master:1 - xfs /dev/sdb1 rw,attr2",
+		"37 36 0:40 /exports /data/my\\040share ro,nosuid - nfs4 srv:/exports rw,vers=4.2",
+	}, "\n")
+
+	mounts, err := parseMountinfo(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Len(t, mounts, 3)
+	assert.Equal(t, "/data/my share", mounts[2].MountPoint)
+	assert.Equal(t, "/exports", mounts[2].Root)
+	assert.Equal(t, "nfs4", mounts[2].FSType)
+	assert.Equal(t, "srv:/exports", mounts[2].Device)
+	assert.True(t, mounts[2].HasOption("ro"))
+
+	assert.Equal(t, "/data", findMount(mounts, "/data/db/file").MountPoint)
+	assert.Equal(t, "/data/my share", findMount(mounts, "/data/my share/x").MountPoint)
+	assert.Equal(t, "/", findMount(mounts, "/database").MountPoint)
+
+	_, err = parseMountinfo(strings.NewReader("bogus line"))
+	assert.Error(t, err)
+}
+
+func TestFS_Volume(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), "not", "created", "yet"))
+	assert.NoError(t, err)
+
+	vol, err := fs.Volume()
+	if runtime.GOOS != "linux" {
+		assert.ErrorIs(t, err, ErrUnsupported)
+		return
+	}
+	assert.NoError(t, err)
+	assert.NotEmpty(t, vol.MountPoint)
+	assert.NotEmpty(t, vol.FSType)
+	assert.True(t, vol.Total >= vol.Free && vol.Free >= vol.Available)
+
+	ok, err := fs.HasSpaceFor(units.Base2Bytes(1))
+	assert.NoError(t, err)
+	assert.Equal(t, vol.Available >= 1, ok)
+}
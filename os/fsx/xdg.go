@@ -0,0 +1,121 @@
+package fsx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FindUp looks for name in the directory, or the directory of the file, and then in each parent.
+// The search ends at the first directory holding one of the stop markers, e.g. ".git" for the
+// repository root, after that directory is checked. The error wraps ErrNotExist when nothing is found.
+func (fs *FS) FindUp(name string, stop ...string) (*FS, error) {
+	for _, dir := range fs.searchDirs() {
+		if found := dir.Join(name); found.Exists() {
+			return found, nil
+		}
+		if dir.hasAny(stop) {
+			break
+		}
+	}
+	return nil, wrapErr("find up", fs.path, name, ErrNotExist)
+}
+
+// FindRoot returns the nearest directory, starting at the directory itself, holding any of the
+// markers such as ".git" or "go.mod".
+func (fs *FS) FindRoot(markers ...string) (*FS, error) {
+	for _, dir := range fs.searchDirs() {
+		if dir.hasAny(markers) {
+			return dir, nil
+		}
+	}
+	return nil, wrapErr("find root", fs.path, "", ErrNotExist)
+}
+
+// searchDirs returns the directory, or the parent of a file, followed by its ancestors.
+func (fs *FS) searchDirs() []*FS {
+	start := fs
+	if !fs.IsDir() {
+		start = fs.Parent()
+	}
+	return append([]*FS{start}, start.Ancestors()...)
+}
+
+func (fs *FS) hasAny(names []string) bool {
+	for _, name := range names {
+		if fs.Join(name).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func ConfigHome() (*FS, error) {
+	return xdgHome("XDG_CONFIG_HOME", ".config")
+}
+
+// DataHome returns $XDG_DATA_HOME, defaulting to ~/.local/share.
+func DataHome() (*FS, error) {
+	return xdgHome("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// CacheHome returns $XDG_CACHE_HOME, defaulting to ~/.cache.
+func CacheHome() (*FS, error) {
+	return xdgHome("XDG_CACHE_HOME", ".cache")
+}
+
+// StateHome returns $XDG_STATE_HOME, defaulting to ~/.local/state.
+func StateHome() (*FS, error) {
+	return xdgHome("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// ConfigDirs returns $XDG_CONFIG_DIRS in order of preference, defaulting to /etc/xdg.
+func ConfigDirs() []*FS {
+	return xdgDirs("XDG_CONFIG_DIRS", "/etc/xdg")
+}
+
+// DataDirs returns $XDG_DATA_DIRS in order of preference, defaulting to /usr/local/share and /usr/share.
+func DataDirs() []*FS {
+	return xdgDirs("XDG_DATA_DIRS", "/usr/local/share:/usr/share")
+}
+
+// FindConfig looks for name, e.g. "tool/config.toml", in ConfigHome and then in ConfigDirs.
+func FindConfig(name string) (*FS, error) {
+	dirs := ConfigDirs()
+	if home, err := ConfigHome(); err == nil {
+		dirs = append([]*FS{home}, dirs...)
+	}
+	for _, dir := range dirs {
+		if found := dir.Join(name); found.Exists() {
+			return found, nil
+		}
+	}
+	return nil, wrapErr("find config", name, "", ErrNotExist)
+}
+
+// xdgHome returns the directory in env, the spec ignores relative paths, or def under the home directory.
+func xdgHome(env, def string) (*FS, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return newAbs(dir), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return newAbs(filepath.Join(home, def)), nil
+}
+
+func xdgDirs(env, def string) []*FS {
+	list := os.Getenv(env)
+	if list == "" {
+		list = def
+	}
+
+	var dirs []*FS
+	for _, dir := range filepath.SplitList(list) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, newAbs(dir))
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,85 @@
+package fsx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_FindUp(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "home")
+	writeTestTree(t, dir, map[string]string{
+		".toolrc":                     "outer\n",
+		"repo/.git/HEAD":              "ref: refs/heads/main\n",
+		"repo/go.mod":                 "module repo\n",
+		"repo/cmd/app/main.go":        "package main\n",
+		"repo/nested/.toolrc":         "nested\n",
+		"repo/nested/deep/readme.txt": "\n",
+	})
+
+	main, err := New(filepath.Join(dir, "repo/cmd/app/main.go"))
+	assert.NoError(t, err)
+
+	root, err := main.FindRoot(".git")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "repo"), root.Path())
+
+	found, err := main.FindUp("go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "repo/go.mod"), found.Path())
+
+	// stops at the repository root
+	_, err = main.FindUp(".toolrc", ".git")
+	assert.ErrorIs(t, err, ErrNotExist)
+	found, err = main.FindUp(".toolrc")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".toolrc"), found.Path())
+
+	deep, err := New(filepath.Join(dir, "repo/nested/deep"))
+	assert.NoError(t, err)
+	found, err = deep.FindUp(".toolrc", ".git")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "repo/nested/.toolrc"), found.Path())
+}
+
+func TestXDG(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "relative/is/ignored")
+	t.Setenv("XDG_CACHE_HOME", "/var/cache/me")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	dir, err := ConfigHome()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config"), dir.Path())
+	dir, err = DataHome()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".local/share"), dir.Path())
+	dir, err = CacheHome()
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/cache/me", dir.Path())
+	dir, err = StateHome()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".local/state"), dir.Path())
+
+	system := t.TempDir()
+	t.Setenv("XDG_CONFIG_DIRS", "rel:"+system+":/etc/xdg")
+	dirs := ConfigDirs()
+	assert.Len(t, dirs, 2)
+	assert.Equal(t, system, dirs[0].Path())
+
+	writeTestTree(t, system, map[string]string{"tool/config.toml": "a = 1\n"})
+	found, err := FindConfig("tool/config.toml")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(system, "tool/config.toml"), found.Path())
+
+	writeTestTree(t, filepath.Join(home, ".config"), map[string]string{"tool/config.toml": "a = 2\n"})
+	found, err = FindConfig("tool/config.toml")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config/tool/config.toml"), found.Path())
+
+	_, err = FindConfig("other/config.toml")
+	assert.ErrorIs(t, err, ErrNotExist)
+}
@@ -0,0 +1,267 @@
+package fsx
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ZipEntry describes one entry of a zip archive.
+type ZipEntry struct {
+	Name           string
+	Size           uint64
+	CompressedSize uint64
+	CRC32          uint32
+	Method         uint16
+	Modified       time.Time
+	Mode           os.FileMode
+	Comment        string
+	Encrypted      bool
+}
+
+// IsDir reports whether the entry is a directory.
+func (e ZipEntry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// MethodName returns the compression method as text, e.g. "deflate".
+func (e ZipEntry) MethodName() string {
+	switch e.Method {
+	case zip.Store:
+		return "store"
+	case zip.Deflate:
+		return "deflate"
+	case zipAESMethod:
+		return "aes"
+	}
+	return fmt.Sprintf("method(%d)", e.Method)
+}
+
+func newZipEntry(f *zip.File) ZipEntry {
+	return ZipEntry{
+		Name:           f.Name,
+		Size:           f.UncompressedSize64,
+		CompressedSize: f.CompressedSize64,
+		CRC32:          f.CRC32,
+		Method:         f.Method,
+		Modified:       f.Modified,
+		Mode:           f.Mode(),
+		Comment:        f.Comment,
+		Encrypted:      f.Flags&zipFlagEncrypted != 0,
+	}
+}
+
+// ZipList lists the entries of the zip archive in archive order.
+func (fs *FS) ZipList() ([]ZipEntry, error) {
+	r, err := zip.OpenReader(fs.path)
+	if err != nil {
+		return nil, wrapErr("zip list", fs.path, "", err)
+	}
+	defer r.Close()
+
+	entries := make([]ZipEntry, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, newZipEntry(f))
+	}
+	return entries, nil
+}
+
+type zipEntryReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (r *zipEntryReader) Close() error {
+	err := r.ReadCloser.Close()
+	if cerr := r.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ZipOpen opens a single entry of the zip archive, closing the reader closes the archive. The CRC
+// is verified when the entry is read to the end.
+func (fs *FS) ZipOpen(name string) (_ io.ReadCloser, err error) {
+	defer func() { err = wrapErr("zip open", fs.path, name, err) }()
+
+	r, err := zip.OpenReader(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := openZipFile(f, "")
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return &zipEntryReader{ReadCloser: rc, archive: r}, nil
+	}
+	r.Close()
+	return nil, ErrNotExist
+}
+
+// UnzipMatch extracts the entries matching any of the glob patterns to dst and returns their names.
+// Patterns follow the include globs of Replace, "*.go" matches base names and "cmd/**" whole paths.
+func (fs *FS) UnzipMatch(dst string, patterns ...string) ([]string, error) {
+	return fs.UnzipMatchWith(context.Background(), dst, UnzipOptions{}, patterns...)
+}
+
+// UnzipMatchWith is UnzipMatch with options, files extracted so far are removed when it fails.
+func (fs *FS) UnzipMatchWith(ctx context.Context, dst string, opts UnzipOptions, patterns ...string) (_ []string, err error) {
+	defer func() { err = wrapErr("unzip", fs.path, dst, err) }()
+
+	r, err := zip.OpenReader(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	dstFs, err := New(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*zip.File
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && matchAnyGlob(patterns, strings.TrimLeft(f.Name, "/")) {
+			matched = append(matched, f)
+		}
+	}
+	total, err := unzipSize(matched, opts.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	t := newTracker(ctx, "unzip", total, opts.Progress)
+
+	var names, written []string
+	defer func() {
+		if err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+	}()
+
+	for _, f := range matched {
+		t.file(f.Name)
+		path, err := fs.unzipFile(f, dstFs, opts.Password, t)
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+		names = append(names, f.Name)
+	}
+	t.finish()
+	return names, nil
+}
+
+// ZipPut adds files to the zip archive, files maps entry names to local paths. Entries with the
+// same name are replaced, all others are copied without recompression. The archive is rewritten
+// to a temporary file and renamed into place, so it is never left half written.
+func (fs *FS) ZipPut(files map[string]string) (err error) {
+	defer func() { err = wrapErr("zip put", fs.path, "", err) }()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if !validEntryName(name) {
+			return fmt.Errorf("%s: %w", name, ErrUnsafePath)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r, err := zip.OpenReader(fs.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(fs.path, info.Mode().Perm(), func(out io.Writer) error {
+		w := zip.NewWriter(out)
+		w.SetComment(r.Comment)
+		for _, f := range r.File {
+			if _, ok := files[f.Name]; ok {
+				continue
+			}
+			if err := w.Copy(f); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			if err := zipAddFile(w, name, files[name]); err != nil {
+				return err
+			}
+		}
+		return w.Close()
+	})
+}
+
+func zipAddFile(w *zip.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	dst, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, file)
+	return err
+}
+
+// ZipTest reads every entry of the zip archive and verifies its CRC without writing anything,
+// the returned error lists every broken entry.
+func (fs *FS) ZipTest() error {
+	r, err := zip.OpenReader(fs.path)
+	if err != nil {
+		return wrapErr("zip test", fs.path, "", err)
+	}
+	defer r.Close()
+
+	var errs []error
+	for _, f := range r.File {
+		if err := zipTestFile(f); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+		}
+	}
+	return wrapErr("zip test", fs.path, "", errors.Join(errs...))
+}
+
+func zipTestFile(f *zip.File) error {
+	rc, err := openZipFile(f, "")
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
@@ -0,0 +1,127 @@
+package fsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestFS_ZipInspectAndUpdate(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, filepath.Join(root, "src"), map[string]string{
+		"cmd/app/main.go": "package main",
+		"README.md":       "# readme",
+		"docs/guide.md":   "guide",
+	})
+
+	src, err := New(filepath.Join(root, "src"))
+	assert.NoError(t, err)
+	archive := filepath.Join(root, "src.zip")
+	assert.NoError(t, src.Zip(archive))
+
+	fs, err := New(archive)
+	assert.NoError(t, err)
+	entries, err := fs.ZipList()
+	assert.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"README.md", "cmd/app/main.go", "docs/guide.md"}, names)
+	assert.Equal(t, uint64(len("# readme")), entries[0].Size)
+	assert.Equal(t, "deflate", entries[0].MethodName())
+
+	rc, err := fs.ZipOpen("cmd/app/main.go")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+	assert.Equal(t, "package main", string(data))
+
+	_, err = fs.ZipOpen("missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	extracted, err := fs.UnzipMatch(filepath.Join(root, "out"), "*.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "docs/guide.md"}, extracted)
+	_, err = os.Stat(filepath.Join(root, "out", "cmd"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "new.md"), []byte("# new"), 0o644))
+	assert.NoError(t, fs.ZipPut(map[string]string{
+		"README.md":    filepath.Join(root, "new.md"),
+		"CHANGELOG.md": filepath.Join(root, "new.md"),
+	}))
+	assert.Error(t, fs.ZipPut(map[string]string{"../evil": filepath.Join(root, "new.md")}))
+
+	entries, err = fs.ZipList()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+	rc, err = fs.ZipOpen("README.md")
+	assert.NoError(t, err)
+	data, err = io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+	assert.Equal(t, "# new", string(data))
+	assert.NoError(t, fs.ZipTest())
+}
+
+func TestFS_ZipTest(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.CreateHeader(&zip.FileHeader{Name: "a.txt", Method: zip.Store})
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("hello world"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	data := bytes.Replace(buf.Bytes(), []byte("hello world"), []byte("jello world"), 1)
+	path := filepath.Join(t.TempDir(), "broken.zip")
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+
+	fs, err := New(path)
+	assert.NoError(t, err)
+	err = fs.ZipTest()
+	assert.ErrorIs(t, err, zip.ErrChecksum)
+	assert.ErrorContains(t, err, "a.txt")
+}
+
+func TestFS_UnzipMatchCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		assert.NoError(t, err)
+		_, err = f.Write([]byte("hello " + name))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	data := bytes.Replace(buf.Bytes(), []byte("hello b.txt"), []byte("jello b.txt"), 1)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.zip")
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+
+	fs, err := New(path)
+	assert.NoError(t, err)
+	out := filepath.Join(dir, "out")
+	names, err := fs.UnzipMatch(out, "*.txt")
+	assert.ErrorIs(t, err, zip.ErrChecksum)
+	assert.Nil(t, names)
+	assert.NoFileExists(t, filepath.Join(out, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(out, "b.txt"))
+}
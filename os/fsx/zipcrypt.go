@@ -0,0 +1,504 @@
+package fsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// ZipEncryption selects how password-protected archives are encrypted.
+type ZipEncryption int
+
+const (
+	// ZipAES256 is WinZip AES with a 256 bit key, the default.
+	ZipAES256 ZipEncryption = iota
+	ZipAES192
+	ZipAES128
+	// ZipCrypto is the legacy PKWARE encryption, it is weak and only meant for old readers.
+	ZipCrypto
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	zipFlagUTF8           = 0x800
+
+	zipAESMethod    = 99
+	zipAESExtraID   = 0x9901
+	zipAESMacLen    = 10
+	zipAESVerifyLen = 2
+	zipAESRounds    = 1000
+
+	zipCryptoHeaderLen = 12
+)
+
+// zipAESExtra is the 0x9901 extra field of an AES encrypted entry.
+type zipAESExtra struct {
+	version  uint16 // 1 for AE-1, 2 for AE-2 which stores no CRC
+	strength byte   // 1, 2 or 3 for 128, 192 or 256 bit keys
+	method   uint16 // actual compression method
+}
+
+func parseZipAESExtra(extra []byte) (zipAESExtra, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == zipAESExtraID && size >= 7 && string(extra[2:4]) == "AE" {
+			e := zipAESExtra{
+				version:  binary.LittleEndian.Uint16(extra),
+				strength: extra[4],
+				method:   binary.LittleEndian.Uint16(extra[5:]),
+			}
+			return e, e.strength >= 1 && e.strength <= 3
+		}
+		extra = extra[size:]
+	}
+	return zipAESExtra{}, false
+}
+
+func (e zipAESExtra) bytes() []byte {
+	b := make([]byte, 11)
+	binary.LittleEndian.PutUint16(b, zipAESExtraID)
+	binary.LittleEndian.PutUint16(b[2:], 7)
+	binary.LittleEndian.PutUint16(b[4:], e.version)
+	copy(b[6:], "AE")
+	b[8] = e.strength
+	binary.LittleEndian.PutUint16(b[9:], e.method)
+	return b
+}
+
+// keyLen returns the AES key length, the salt is half as long.
+func (e zipAESExtra) keyLen() int {
+	return 8 + 8*int(e.strength)
+}
+
+// zipAESKeys derives the AES key, the HMAC-SHA1 key and the password verifier.
+func zipAESKeys(password string, salt []byte, keyLen int) (key, macKey, verifier []byte) {
+	dk := pbkdf2Key(sha1.New, []byte(password), salt, zipAESRounds, 2*keyLen+zipAESVerifyLen)
+	return dk[:keyLen], dk[keyLen : 2*keyLen], dk[2*keyLen:]
+}
+
+// zipCTR is AES-CTR as used by WinZip, with a little-endian counter starting at 1.
+type zipCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	used    int
+}
+
+func newZipCTR(key []byte) (*zipCTR, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &zipCTR{block: block, used: aes.BlockSize}, nil
+}
+
+func (c *zipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.used == aes.BlockSize {
+			for j := range c.counter {
+				c.counter[j]++
+				if c.counter[j] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.stream[:], c.counter[:])
+			c.used = 0
+		}
+		dst[i] = src[i] ^ c.stream[c.used]
+		c.used++
+	}
+}
+
+// zipAESReader decrypts the data of an AES entry and verifies the authentication code at the end.
+type zipAESReader struct {
+	raw  io.Reader
+	data *io.LimitedReader
+	ctr  *zipCTR
+	mac  hash.Hash
+	done bool
+}
+
+func newZipAESReader(raw io.Reader, size uint64, extra zipAESExtra, password string) (io.Reader, error) {
+	keyLen := extra.keyLen()
+	overhead := uint64(keyLen/2 + zipAESVerifyLen + zipAESMacLen)
+	if size < overhead {
+		return nil, zip.ErrFormat
+	}
+
+	header := make([]byte, keyLen/2+zipAESVerifyLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	key, macKey, verifier := zipAESKeys(password, header[:keyLen/2], keyLen)
+	if subtle.ConstantTimeCompare(verifier, header[keyLen/2:]) != 1 {
+		return nil, ErrPassword
+	}
+
+	ctr, err := newZipCTR(key)
+	if err != nil {
+		return nil, err
+	}
+	return &zipAESReader{
+		raw:  raw,
+		data: &io.LimitedReader{R: raw, N: int64(size - overhead)},
+		ctr:  ctr,
+		mac:  hmac.New(sha1.New, macKey),
+	}, nil
+}
+
+func (r *zipAESReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	n, err := r.data.Read(p)
+	r.mac.Write(p[:n])
+	r.ctr.XORKeyStream(p[:n], p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+	if r.data.N > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	r.done = true
+	code := make([]byte, zipAESMacLen)
+	if _, err := io.ReadFull(r.raw, code); err != nil {
+		return n, err
+	}
+	if !hmac.Equal(code, r.mac.Sum(nil)[:zipAESMacLen]) {
+		return n, ErrDecrypt
+	}
+	return n, io.EOF
+}
+
+// zipCryptoKeys is the state of the legacy PKWARE stream cipher.
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	k := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, c := range []byte(password) {
+		k.update(c)
+	}
+	return k
+}
+
+func (k *zipCryptoKeys) update(c byte) {
+	k[0] = crc32Update(k[0], c)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) next() byte {
+	t := k[2]&0xffff | 2
+	return byte(t * (t ^ 1) >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(b []byte) {
+	for i, c := range b {
+		b[i] = c ^ k.next()
+		k.update(b[i])
+	}
+}
+
+func (k *zipCryptoKeys) encrypt(b []byte) {
+	for i, c := range b {
+		b[i] = c ^ k.next()
+		k.update(c)
+	}
+}
+
+func crc32Update(crc uint32, c byte) uint32 {
+	return crc32.IEEETable[byte(crc)^c] ^ crc>>8
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+// newZipCryptoReader decrypts the 12 byte encryption header, its last byte must match check.
+func newZipCryptoReader(raw io.Reader, password string, check byte) (io.Reader, error) {
+	header := make([]byte, zipCryptoHeaderLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys := newZipCryptoKeys(password)
+	keys.decrypt(header)
+	if header[zipCryptoHeaderLen-1] != check {
+		return nil, ErrPassword
+	}
+	return &zipCryptoReader{r: raw, keys: keys}, nil
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.keys.decrypt(p[:n])
+	return n, err
+}
+
+// zipCheckReader verifies the size and, unless it is an AE-2 entry, the CRC of an entry read through
+// OpenRaw like zip.File.Open does. The decompressor stops at the end of the compressed stream, the
+// rest of the decrypted data is drained so the AES authentication code is always checked.
+type zipCheckReader struct {
+	io.ReadCloser
+	decrypted io.Reader
+	hash      hash.Hash32
+	n, size   uint64
+	crc       uint32
+	checkCRC  bool
+}
+
+func (r *zipCheckReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	r.n += uint64(n)
+	if err != io.EOF {
+		if err == nil && r.n > r.size {
+			err = zip.ErrFormat
+		}
+		return n, err
+	}
+	if r.n != r.size {
+		return n, io.ErrUnexpectedEOF
+	}
+	if _, err := io.Copy(io.Discard, r.decrypted); err != nil {
+		return n, err
+	}
+	if r.checkCRC && r.hash.Sum32() != r.crc {
+		return n, zip.ErrChecksum
+	}
+	return n, io.EOF
+}
+
+func zipDecompressor(method uint16, r io.Reader) (io.ReadCloser, error) {
+	switch method {
+	case zip.Store:
+		return io.NopCloser(r), nil
+	case zip.Deflate:
+		return flate.NewReader(r), nil
+	}
+	return nil, zip.ErrAlgorithm
+}
+
+// openZipFile opens an entry like zip.File.Open, decrypting AES and ZipCrypto entries with password.
+// A missing or wrong password fails with ErrPassword, a failed AES authentication with ErrDecrypt.
+func openZipFile(f *zip.File, password string) (io.ReadCloser, error) {
+	if f.Flags&zipFlagEncrypted == 0 && f.Method != zipAESMethod {
+		return f.Open()
+	}
+	if password == "" {
+		return nil, ErrPassword
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	method, checkCRC := f.Method, true
+	if f.Method == zipAESMethod {
+		extra, ok := parseZipAESExtra(f.Extra)
+		if !ok {
+			return nil, zip.ErrFormat
+		}
+		method, checkCRC = extra.method, extra.version == 1
+		r, err = newZipAESReader(raw, f.CompressedSize64, extra, password)
+	} else {
+		check := byte(f.CRC32 >> 24)
+		if f.Flags&zipFlagDataDescriptor != 0 {
+			check = byte(f.ModifiedTime >> 8)
+		}
+		r, err = newZipCryptoReader(raw, password, check)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := zipDecompressor(method, r)
+	if err != nil {
+		return nil, err
+	}
+	return &zipCheckReader{ReadCloser: rc, decrypted: r, hash: crc32.NewIEEE(), size: f.UncompressedSize64, crc: f.CRC32, checkCRC: checkCRC}, nil
+}
+
+// zipAddEncrypted adds the file read from r to the archive, deflated and encrypted with password as
+// it is read. The CRC and sizes only become known at the end, they go into a data descriptor.
+func zipAddEncrypted(w *zip.Writer, name string, info os.FileInfo, r io.Reader, password string, enc ZipEncryption) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	header.Flags = zipFlagEncrypted | zipFlagDataDescriptor
+	if !isASCII(name) && utf8.ValidString(name) {
+		header.Flags |= zipFlagUTF8
+	}
+	header.ModifiedDate, header.ModifiedTime = msDosTime(info.ModTime())
+
+	if enc == ZipCrypto {
+		return zipWriteCrypto(w, header, r, password)
+	}
+	return zipWriteAES(w, header, r, password, enc)
+}
+
+func zipWriteCrypto(w *zip.Writer, header *zip.FileHeader, r io.Reader, password string) error {
+	header.ReaderVersion = 20
+
+	// with a data descriptor the check byte is the high byte of the time instead of the CRC
+	encHeader := make([]byte, zipCryptoHeaderLen)
+	if _, err := rand.Read(encHeader[:zipCryptoHeaderLen-1]); err != nil {
+		return err
+	}
+	encHeader[zipCryptoHeaderLen-1] = byte(header.ModifiedTime >> 8)
+	keys := newZipCryptoKeys(password)
+	keys.encrypt(encHeader)
+
+	out, err := w.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(encHeader); err != nil {
+		return err
+	}
+	return zipDeflateTo(header, &zipEncryptWriter{w: out, transform: keys.encrypt}, r, zipCryptoHeaderLen)
+}
+
+func zipWriteAES(w *zip.Writer, header *zip.FileHeader, r io.Reader, password string, enc ZipEncryption) error {
+	// AE-2 leaves out the CRC, which could reveal the content of tiny files
+	head := make([]byte, 20)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	r = io.MultiReader(bytes.NewReader(head[:n]), r)
+	extra := zipAESExtra{version: 1, strength: byte(3 - enc), method: zip.Deflate}
+	if n < len(head) {
+		extra.version = 2
+	}
+	keyLen := extra.keyLen()
+
+	salt := make([]byte, keyLen/2)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, macKey, verifier := zipAESKeys(password, salt, keyLen)
+	ctr, err := newZipCTR(key)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha1.New, macKey)
+
+	header.Method = zipAESMethod
+	header.ReaderVersion = 51
+	header.Extra = append(header.Extra, extra.bytes()...)
+
+	out, err := w.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append(salt, verifier...)); err != nil {
+		return err
+	}
+	data := &zipEncryptWriter{w: out, transform: func(b []byte) {
+		ctr.XORKeyStream(b, b)
+		mac.Write(b)
+	}}
+	if err := zipDeflateTo(header, data, r, len(salt)+len(verifier)+zipAESMacLen); err != nil {
+		return err
+	}
+	if extra.version == 2 {
+		header.CRC32 = 0
+	}
+	_, err = out.Write(mac.Sum(nil)[:zipAESMacLen])
+	return err
+}
+
+// zipDeflateTo deflates r into w and fills in the CRC and sizes of header, overhead is the number of
+// encryption bytes around the data. CreateRaw keeps header and writes them in the data descriptor
+// and central directory once the entry is done.
+func zipDeflateTo(header *zip.FileHeader, w *zipEncryptWriter, r io.Reader, overhead int) error {
+	crc := crc32.NewIEEE()
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(io.MultiWriter(fw, crc), r)
+	if err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	header.CRC32 = crc.Sum32()
+	header.UncompressedSize64 = uint64(size)
+	header.CompressedSize64 = uint64(w.n) + uint64(overhead)
+	header.CompressedSize = zipSize32(header.CompressedSize64)
+	header.UncompressedSize = zipSize32(header.UncompressedSize64)
+	return nil
+}
+
+// zipSize32 is the 32 bit size field for size, all ones when it needs the zip64 field.
+func zipSize32(size uint64) uint32 {
+	if size >= 0xffffffff {
+		return 0xffffffff
+	}
+	return uint32(size)
+}
+
+// zipEncryptWriter encrypts the compressed data on its way to the archive and counts it.
+type zipEncryptWriter struct {
+	w         io.Writer
+	transform func([]byte)
+	buf       []byte
+	n         int64
+}
+
+func (w *zipEncryptWriter) Write(p []byte) (int, error) {
+	// the compressor still owns p, transform a copy
+	w.buf = append(w.buf[:0], p...)
+	w.transform(w.buf)
+	n, err := w.w.Write(w.buf)
+	w.n += int64(n)
+	return n, err
+}
+
+// msDosTime converts t to the date and time fields of zip headers, CreateRaw does not fill them in.
+func msDosTime(t time.Time) (date, clock uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package fsx
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS_ZipPassword(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"tiny.txt":      "hi\n",
+		"sub/large.txt": string(make([]byte, 100_000)) + "end\n",
+	}
+	writeTestTree(t, src, files)
+	srcFs, err := New(src)
+	assert.NoError(t, err)
+
+	for _, enc := range []ZipEncryption{ZipAES256, ZipAES192, ZipAES128, ZipCrypto} {
+		archive := filepath.Join(dir, "enc.zip")
+		os.Remove(archive)
+		assert.NoError(t, srcFs.ZipWith(context.Background(), archive, ZipOptions{Password: "s3cret", Encryption: enc}))
+
+		zfs, err := New(archive)
+		assert.NoError(t, err)
+		entries, err := zfs.ZipList()
+		assert.NoError(t, err)
+		for _, e := range entries {
+			assert.True(t, e.Encrypted)
+		}
+
+		out := filepath.Join(dir, "out")
+		os.RemoveAll(out)
+		assert.ErrorIs(t, zfs.Unzip(out), ErrPassword)
+		assert.ErrorIs(t, zfs.UnzipWith(context.Background(), out, UnzipOptions{Password: "wrong"}), ErrPassword)
+		assert.NoFileExists(t, filepath.Join(out, "tiny.txt"))
+		assert.ErrorIs(t, zfs.ZipTest(), ErrPassword)
+
+		assert.NoError(t, zfs.UnzipWith(context.Background(), out, UnzipOptions{Password: "s3cret"}))
+		for name, content := range files {
+			data, err := os.ReadFile(filepath.Join(out, name))
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(data), "%d %s", enc, name)
+		}
+	}
+}
+
+func TestFS_UnzipAESTampered(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "data.txt")
+	assert.NoError(t, os.WriteFile(src, []byte("attack at dawn, attack at dawn\n"), 0o644))
+	srcFs, err := New(src)
+	assert.NoError(t, err)
+
+	archive := filepath.Join(dir, "data.zip")
+	assert.NoError(t, srcFs.ZipWith(context.Background(), archive, ZipOptions{Password: "pw"}))
+
+	// flip a bit of the last ciphertext byte, right before the authentication code
+	r, err := zip.OpenReader(archive)
+	assert.NoError(t, err)
+	offset, err := r.File[0].DataOffset()
+	assert.NoError(t, err)
+	end := offset + int64(r.File[0].CompressedSize64) - zipAESMacLen - 1
+	r.Close()
+
+	data, err := os.ReadFile(archive)
+	assert.NoError(t, err)
+	data[end] ^= 1
+	assert.NoError(t, os.WriteFile(archive, data, 0o644))
+
+	zfs, err := New(archive)
+	assert.NoError(t, err)
+	out := filepath.Join(dir, "out")
+	assert.ErrorIs(t, zfs.UnzipWith(context.Background(), out, UnzipOptions{Password: "pw"}), ErrDecrypt)
+	assert.NoFileExists(t, filepath.Join(out, "data.txt"))
+}
+
+// TestFS_UnzipForeignEncrypted reads archives written by other tools, so a bug shared by our writer
+// and reader cannot hide. The libarchive ones were made with
+// "bsdtar --format zip --options zip:encryption=aes128|aes256 --passphrase fixture-secret" and hold
+// AE-1 deflated entries and an AE-2 stored one, the Info-ZIP one with "zip -P fixture-secret".
+func TestFS_UnzipForeignEncrypted(t *testing.T) {
+	long := ""
+	for i := 0; i < 200; i++ {
+		long += fmt.Sprintf("line %d of a file long enough to deflate\n", i)
+	}
+	want := map[string]string{
+		"hello.txt": "hello from libarchive\n",
+		"long.txt":  long,
+		"tiny.txt":  "tiny",
+	}
+
+	for _, name := range []string{"libarchive-aes128.zip", "libarchive-aes256.zip", "infozip-zipcrypto.zip"} {
+		zfs, err := New(filepath.Join("testdata", name))
+		assert.NoError(t, err)
+
+		out := filepath.Join(t.TempDir(), "out")
+		assert.ErrorIs(t, zfs.UnzipWith(context.Background(), out, UnzipOptions{Password: "wrong"}), ErrPassword, name)
+		assert.NoError(t, zfs.UnzipWith(context.Background(), out, UnzipOptions{Password: "fixture-secret"}), name)
+		for file, content := range want {
+			got, err := os.ReadFile(filepath.Join(out, file))
+			assert.NoError(t, err, name)
+			assert.Equal(t, content, string(got), name+" "+file)
+		}
+	}
+}
+
+// TestFS_ZipPasswordInterop checks that Info-ZIP unzip reads our ZipCrypto archives, with a symlink
+// stored as the regular file it points to.
+func TestFS_ZipPasswordInterop(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeTestTree(t, src, map[string]string{"a.txt": "hello\n", "big.txt": string(make([]byte, 50_000))})
+	assert.NoError(t, os.Symlink("a.txt", filepath.Join(src, "link")))
+	srcFs, err := New(src)
+	assert.NoError(t, err)
+
+	archive := filepath.Join(dir, "enc.zip")
+	assert.NoError(t, srcFs.ZipWith(context.Background(), archive, ZipOptions{Password: "s3cret", Encryption: ZipCrypto}))
+
+	r, err := zip.OpenReader(archive)
+	assert.NoError(t, err)
+	for _, f := range r.File {
+		assert.True(t, f.Mode().IsRegular(), f.Name)
+	}
+	r.Close()
+
+	unzip, err := exec.LookPath("unzip")
+	if err != nil {
+		t.Skip("unzip not installed")
+	}
+	out := filepath.Join(dir, "out")
+	output, err := exec.Command(unzip, "-P", "s3cret", "-d", out, archive).CombinedOutput()
+	assert.NoError(t, err, string(output))
+
+	info, err := os.Lstat(filepath.Join(out, "link"))
+	assert.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	data, err := os.ReadFile(filepath.Join(out, "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+	data, err = os.ReadFile(filepath.Join(out, "big.txt"))
+	assert.NoError(t, err)
+	assert.Len(t, data, 50_000)
+}
@@ -0,0 +1,202 @@
+package urlx
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// NormalizeOptions configures Normalize, the RFC 3986 normalizations are always applied.
+type NormalizeOptions struct {
+	// StripTracking removes the utm_* parameters, fbclid and gclid.
+	StripTracking bool
+	// StripParams removes more parameters, a trailing "*" matches a prefix like in "utm_*".
+	StripParams []string
+	// StripTrailingSlash removes a trailing slash from the path, "/" itself is kept.
+	StripTrailingSlash bool
+	// StripFragment removes the fragment.
+	StripFragment bool
+}
+
+// TrackingParams are the parameters removed by NormalizeOptions.StripTracking.
+var TrackingParams = []string{"utm_*", "fbclid", "gclid"}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// Normalize rewrites the URL to its normal form (RFC 3986 section 6): the scheme and host are
+// lowercased, default ports dropped, dot-segments removed, percent-encodings uppercased and decoded
+// for unreserved characters, and query keys sorted.
+func (u *URL) Normalize(opts NormalizeOptions) *URL {
+	u.u.Scheme = strings.ToLower(u.u.Scheme)
+	u.u.Host = strings.ToLower(u.u.Host)
+	if host, port, err := net.SplitHostPort(u.u.Host); err == nil && (port == "" || port == defaultPorts[u.u.Scheme]) {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		u.u.Host = host
+	} else if strings.HasSuffix(u.u.Host, ":") {
+		u.u.Host = strings.TrimSuffix(u.u.Host, ":")
+	}
+
+	path := removeDotSegments(normalizePercent(u.u.EscapedPath()))
+	if path == "" && u.u.Host != "" {
+		path = "/"
+	}
+	if opts.StripTrailingSlash && len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	u.setEscapedPath(path)
+
+	q := u.QueryParams()
+	for i := range q.params {
+		q.params[i].raw = normalizePercent(q.params[i].raw)
+	}
+	var strip []string
+	if opts.StripTracking {
+		strip = append(strip, TrackingParams...)
+	}
+	strip = append(strip, opts.StripParams...)
+	for _, key := range q.Keys() {
+		if matchParam(strip, key) {
+			q.Del(key)
+		}
+	}
+	q.Sort()
+	u.u.ForceQuery = false
+
+	if opts.StripFragment {
+		u.u.Fragment, u.u.RawFragment = "", ""
+	} else if u.u.Fragment != "" {
+		fragment := normalizePercent(u.u.EscapedFragment())
+		if f, err := url.PathUnescape(fragment); err == nil {
+			u.u.Fragment, u.u.RawFragment = f, fragment
+		}
+	}
+	return u
+}
+
+// Equivalent reports whether a and b are the same after normalizing copies of them with opts.
+func Equivalent(a, b *URL, opts NormalizeOptions) bool {
+	return a.Clone().Normalize(opts).String() == b.Clone().Normalize(opts).String()
+}
+
+// Clone returns a copy of the URL.
+func (u *URL) Clone() *URL {
+	c := *u.u
+	clone := &URL{u: &c}
+	if u.query != nil {
+		clone.query = u.QueryParams().Clone()
+	}
+	return clone
+}
+
+// setEscapedPath sets the path from its escaped form, RawPath is only kept when it differs from the
+// default encoding of Path.
+func (u *URL) setEscapedPath(escaped string) {
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return
+	}
+	u.u.Path, u.u.RawPath = path, escaped
+	if u.u.EscapedPath() != escaped || (&url.URL{Path: path}).EscapedPath() == escaped {
+		u.u.RawPath = ""
+	}
+}
+
+func matchParam(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(key, prefix) || pattern == key {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePercent uppercases the hex digits of percent-encodings and decodes unreserved characters.
+func normalizePercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(s[i+1:i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// removeDotSegments removes "." and ".." segments from a path, RFC 3986 section 5.2.4.
+func removeDotSegments(in string) string {
+	if !strings.Contains(in, ".") {
+		return in
+	}
+	trimLast := func(out string) string {
+		if i := strings.LastIndexByte(out, '/'); i >= 0 {
+			return out[:i]
+		}
+		return ""
+	}
+
+	out := ""
+	for in != "" {
+		switch {
+		case strings.HasPrefix(in, "../"):
+			in = in[3:]
+		case strings.HasPrefix(in, "./"), strings.HasPrefix(in, "/./"):
+			in = in[2:]
+		case in == "/.":
+			in = "/"
+		case strings.HasPrefix(in, "/../"):
+			in, out = in[3:], trimLast(out)
+		case in == "/..":
+			in, out = "/", trimLast(out)
+		case in == "." || in == "..":
+			in = ""
+		default:
+			start := 0
+			if in[0] == '/' {
+				start = 1
+			}
+			end := len(in)
+			if i := strings.IndexByte(in[start:], '/'); i >= 0 {
+				end = start + i
+			}
+			out, in = out+in[:end], in[end:]
+		}
+	}
+	return out
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,53 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL_Normalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts NormalizeOptions
+		want string
+	}{
+		{"HTTP://Example.COM:80/a/./b/../c?b=2&a=1#Frag", NormalizeOptions{}, "http://example.com/a/c?a=1&b=2#Frag"},
+		{"https://example.com:443", NormalizeOptions{}, "https://example.com/"},
+		{"https://example.com:8443/", NormalizeOptions{}, "https://example.com:8443/"},
+		{"http://[::1]:80/x", NormalizeOptions{}, "http://[::1]/x"},
+		{"http://example.com/%7euser/%2fdoc%3f", NormalizeOptions{}, "http://example.com/~user/%2Fdoc%3F"},
+		{"http://example.com/p?q=%7e%2f", NormalizeOptions{}, "http://example.com/p?q=~%2F"},
+		{
+			"https://example.com/post/?utm_source=x&id=7&fbclid=y&gclid=z&utm_medium=m#top",
+			NormalizeOptions{StripTracking: true, StripTrailingSlash: true, StripFragment: true},
+			"https://example.com/post?id=7",
+		},
+		{"https://example.com/?ref=a&x=1", NormalizeOptions{StripParams: []string{"ref"}}, "https://example.com/?x=1"},
+	}
+	for _, tt := range tests {
+		u, err := FromString(tt.in)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, u.Normalize(tt.opts).String(), tt.in)
+	}
+}
+
+func TestRemoveDotSegments(t *testing.T) {
+	// RFC 3986 section 5.2.4
+	assert.Equal(t, "/a/g", removeDotSegments("/a/b/c/./../../g"))
+	assert.Equal(t, "mid/6", removeDotSegments("mid/content=5/../6"))
+	assert.Equal(t, "/", removeDotSegments("/.."))
+	assert.Equal(t, "/a/", removeDotSegments("/a/b/.."))
+}
+
+func TestEquivalent(t *testing.T) {
+	a, err := FromString("https://Example.com:443/docs/../guide?b=1&a=2&utm_campaign=x")
+	assert.NoError(t, err)
+	b, err := FromString("https://example.com/guide?a=2&b=1")
+	assert.NoError(t, err)
+
+	assert.False(t, Equivalent(a, b, NormalizeOptions{}))
+	assert.True(t, Equivalent(a, b, NormalizeOptions{StripTracking: true}))
+	// the inputs are left alone
+	assert.Equal(t, "https://Example.com:443/docs/../guide?b=1&a=2&utm_campaign=x", a.String())
+}
@@ -0,0 +1,290 @@
+package urlx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pattern is a path pattern such as "/users/{id:[0-9]+}/posts/{slug}" or "/static/{path...}".
+// A parameter fills a whole segment and may be constrained by a regular expression or one of the
+// named types such as "{id:int}", a final "{name...}" matches the rest of the path. Trailing slashes are
+// ignored when matching.
+type Pattern struct {
+	raw      string
+	segments []patternSegment
+}
+
+type segmentKind int
+
+// segment kinds in order of specificity
+const (
+	segmentCatchAll segmentKind = iota
+	segmentParam
+	segmentRegexp
+	segmentLiteral
+)
+
+type patternSegment struct {
+	kind    segmentKind
+	literal string // the literal, or the parameter name
+	re      *regexp.Regexp
+}
+
+// patternTypes are the constraints usable by name, "{id:int}" is "{id:-?[0-9]+}".
+var patternTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[A-Za-z]+`,
+	"alnum": `[A-Za-z0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// PatternOptions configures ParsePatternWith.
+type PatternOptions struct {
+	// Types are named constraints added to int, uint, alpha, alnum and uuid, replacing those of the
+	// same name.
+	Types map[string]string
+}
+
+// ParsePattern compiles a path pattern.
+func ParsePattern(s string) (*Pattern, error) {
+	return ParsePatternWith(s, PatternOptions{})
+}
+
+// ParsePatternWith is ParsePattern with custom named constraints.
+func ParsePatternWith(s string, opts PatternOptions) (*Pattern, error) {
+	p := &Pattern{raw: s}
+	segments, err := splitPattern(strings.TrimSuffix(strings.TrimPrefix(s, "/"), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", s, err)
+	}
+
+	seen := map[string]bool{}
+	for i, segment := range segments {
+		seg, err := parsePatternSegment(segment, opts.Types)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", s, err)
+		}
+		if seg.kind != segmentLiteral {
+			if seen[seg.literal] {
+				return nil, fmt.Errorf("pattern %q: duplicate parameter %q", s, seg.literal)
+			}
+			seen[seg.literal] = true
+		}
+		if seg.kind == segmentCatchAll && i != len(segments)-1 {
+			return nil, fmt.Errorf("pattern %q: %q must be the last segment", s, segment)
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// MustParsePattern is ParsePattern panicking on errors, for patterns in variables.
+func MustParsePattern(s string) *Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// splitPattern splits at slashes outside of braces, so constraints may contain "/" and "{n}".
+func splitPattern(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var segments []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unexpected '}'")
+			}
+			depth--
+		case '/':
+			if depth == 0 {
+				segments = append(segments, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unclosed '{'")
+	}
+	return append(segments, s[start:]), nil
+}
+
+func parsePatternSegment(segment string, types map[string]string) (patternSegment, error) {
+	if !strings.HasPrefix(segment, "{") {
+		if strings.ContainsAny(segment, "{}") {
+			return patternSegment{}, fmt.Errorf("parameter in %q must fill the whole segment", segment)
+		}
+		return patternSegment{kind: segmentLiteral, literal: segment}, nil
+	}
+	if !strings.HasSuffix(segment, "}") {
+		return patternSegment{}, fmt.Errorf("parameter in %q must fill the whole segment", segment)
+	}
+
+	expr := segment[1 : len(segment)-1]
+	seg := patternSegment{kind: segmentParam, literal: expr}
+	if name, ok := strings.CutSuffix(expr, "..."); ok {
+		seg.kind, seg.literal = segmentCatchAll, name
+	} else if name, constraint, ok := strings.Cut(expr, ":"); ok {
+		if typ, ok := types[constraint]; ok {
+			constraint = typ
+		} else if typ, ok := patternTypes[constraint]; ok {
+			constraint = typ
+		}
+		re, err := regexp.Compile("^(?:" + constraint + ")$")
+		if err != nil {
+			return patternSegment{}, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		seg.kind, seg.literal, seg.re = segmentRegexp, name, re
+	}
+	if !validParamName(seg.literal) {
+		return patternSegment{}, fmt.Errorf("invalid parameter name %q", seg.literal)
+	}
+	return seg, nil
+}
+
+func validParamName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// String returns the pattern as parsed.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Params are the parameters of a matched pattern, values are unescaped.
+type Params map[string]string
+
+// Get returns the parameter, or "" when it is not set.
+func (ps Params) Get(name string) string {
+	return ps[name]
+}
+
+// Int returns the parameter parsed as an int.
+func (ps Params) Int(name string) (int, error) {
+	v, ok := ps[name]
+	if !ok {
+		return 0, fmt.Errorf("param %q not set", name)
+	}
+	return strconv.Atoi(v)
+}
+
+// Match matches the path of u against the pattern, ok is false when it does not match.
+func (p *Pattern) Match(u *URL) (params Params, ok bool) {
+	segments := u.Segments()
+	params = Params{}
+	for i, seg := range p.segments {
+		if seg.kind == segmentCatchAll && i <= len(segments) {
+			params[seg.literal] = strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		switch seg.kind {
+		case segmentLiteral:
+			if segments[i] != seg.literal {
+				return nil, false
+			}
+			continue
+		case segmentRegexp:
+			if !seg.re.MatchString(segments[i]) {
+				return nil, false
+			}
+		}
+		params[seg.literal] = segments[i]
+	}
+	if len(segments) != len(p.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// MoreSpecific reports whether p is more specific than q. Segments are compared from the left,
+// a literal beats a constrained parameter, which beats a plain parameter, which beats a catch-all.
+func (p *Pattern) MoreSpecific(q *Pattern) bool {
+	for i := 0; i < len(p.segments) && i < len(q.segments); i++ {
+		if a, b := p.segments[i].kind, q.segments[i].kind; a != b {
+			return a > b
+		}
+	}
+	switch {
+	case len(p.segments) > len(q.segments):
+		// "/static/{path...}" also matches "/static", where the literal pattern should win
+		return p.segments[len(q.segments)].kind != segmentCatchAll
+	case len(p.segments) < len(q.segments):
+		return q.segments[len(p.segments)].kind == segmentCatchAll
+	}
+	return false
+}
+
+// BestMatch returns the most specific of the patterns matching u and its parameters, or nil when
+// none match. Of equally specific patterns the first one wins.
+func BestMatch(patterns []*Pattern, u *URL) (*Pattern, Params) {
+	var best *Pattern
+	var bestParams Params
+	for _, p := range patterns {
+		params, ok := p.Match(u)
+		if ok && (best == nil || p.MoreSpecific(best)) {
+			best, bestParams = p, params
+		}
+	}
+	return best, bestParams
+}
+
+// Build returns the path of the pattern with the parameters filled in and escaped, a catch-all
+// value keeps its slashes. Missing parameters, values not matching their constraint and catch-all
+// values with empty, "." or ".." segments are errors, dot segments would escape the pattern once
+// the path is normalized.
+func (p *Pattern) Build(params Params) (*URL, error) {
+	var segments []string
+	for _, seg := range p.segments {
+		if seg.kind == segmentLiteral {
+			segments = append(segments, seg.literal)
+			continue
+		}
+
+		v, ok := params[seg.literal]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("pattern %q: param %q not set", p.raw, seg.literal)
+		case seg.kind == segmentCatchAll:
+			if v == "" {
+				continue
+			}
+			for _, segment := range strings.Split(v, "/") {
+				if segment == "" || segment == "." || segment == ".." {
+					return nil, fmt.Errorf("pattern %q: param %q has an empty or dot segment in %q", p.raw, seg.literal, v)
+				}
+				segments = append(segments, segment)
+			}
+			continue
+		case v == "", v == ".", v == "..":
+			return nil, fmt.Errorf("pattern %q: param %q is empty or a dot segment", p.raw, seg.literal)
+		case seg.re != nil && !seg.re.MatchString(v):
+			return nil, fmt.Errorf("pattern %q: param %q value %q does not match its constraint", p.raw, seg.literal, v)
+		}
+		segments = append(segments, v)
+	}
+
+	u, err := FromString("")
+	if err != nil {
+		return nil, err
+	}
+	return u.setSegments(segments, len(segments) == 0), nil
+}
@@ -0,0 +1,130 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPattern_Match(t *testing.T) {
+	p := MustParsePattern("/users/{id:[0-9]+}/posts/{slug}")
+
+	u, _ := FromString("https://example.com/users/42/posts/hello%20world/?page=2")
+	params, ok := p.Match(u)
+	assert.True(t, ok)
+	assert.Equal(t, Params{"id": "42", "slug": "hello world"}, params)
+	id, err := params.Int("id")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	for _, path := range []string{"/users/abc/posts/x", "/users/42/posts", "/users/42/posts/x/y", "/"} {
+		u, _ := FromString(path)
+		_, ok := p.Match(u)
+		assert.False(t, ok, path)
+	}
+
+	static := MustParsePattern("/static/{path...}")
+	u, _ = FromString("/static/css/a%2Fb/site.css")
+	params, ok = static.Match(u)
+	assert.True(t, ok)
+	assert.Equal(t, "css/a/b/site.css", params.Get("path"))
+
+	u, _ = FromString("/static")
+	params, ok = static.Match(u)
+	assert.True(t, ok)
+	assert.Equal(t, "", params.Get("path"))
+
+	u, _ = FromString("/")
+	_, ok = static.Match(u)
+	assert.False(t, ok)
+
+	typed := MustParsePattern("/orders/{id:uuid}/{n:int}")
+	u, _ = FromString("/orders/0b5e6f1c-3a2d-4c5e-9f00-1234567890ab/-3")
+	params, ok = typed.Match(u)
+	assert.True(t, ok)
+	n, err := params.Int("n")
+	assert.NoError(t, err)
+	assert.Equal(t, -3, n)
+
+	custom, err := ParsePatternWith("/{lang:lang}/{id:int}", PatternOptions{Types: map[string]string{"lang": `[a-z]{2}`, "int": `[1-9][0-9]*`}})
+	assert.NoError(t, err)
+	for path, want := range map[string]bool{"/en/12": true, "/eng/12": false, "/en/-3": false, "/en/012": false} {
+		u, _ := FromString(path)
+		_, ok := custom.Match(u)
+		assert.Equal(t, want, ok, path)
+	}
+	// types of one pattern do not leak into others
+	u, _ = FromString("/en/-3")
+	_, ok = MustParsePattern("/{lang}/{id:int}").Match(u)
+	assert.True(t, ok)
+	_, err = ParsePattern("/{lang:lang}")
+	assert.NoError(t, err, "unknown names are regular expressions")
+
+	for _, bad := range []string{"/a/{id", "/a/}", "/a/x{id}", "/{a}/{a}", "/{path...}/x", "/{id:[}", "/{}", "/{a-b}"} {
+		_, err := ParsePattern(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	patterns := []*Pattern{
+		MustParsePattern("/{path...}"),
+		MustParsePattern("/users/{name}"),
+		MustParsePattern("/users/{id:int}"),
+		MustParsePattern("/users/me"),
+		MustParsePattern("/static/{path...}"),
+		MustParsePattern("/static"),
+	}
+	tests := map[string]string{
+		"/users/me":      "/users/me",
+		"/users/42":      "/users/{id:int}",
+		"/users/bob":     "/users/{name}",
+		"/static":        "/static",
+		"/static/app.js": "/static/{path...}",
+		"/other/page":    "/{path...}",
+		"/":              "/{path...}",
+	}
+	for path, want := range tests {
+		u, _ := FromString(path)
+		p, _ := BestMatch(patterns, u)
+		if assert.NotNil(t, p, path) {
+			assert.Equal(t, want, p.String(), path)
+		}
+	}
+
+	u, _ := FromString("/nothing")
+	p, params := BestMatch(patterns[1:4], u)
+	assert.Nil(t, p)
+	assert.Nil(t, params)
+}
+
+func TestPattern_Build(t *testing.T) {
+	p := MustParsePattern("/users/{id:int}/posts/{slug}")
+	u, err := p.Build(Params{"id": "7", "slug": "a/b c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/7/posts/a%2Fb%20c", u.String())
+
+	params, ok := p.Match(u)
+	assert.True(t, ok)
+	assert.Equal(t, Params{"id": "7", "slug": "a/b c"}, params)
+
+	_, err = p.Build(Params{"id": "x", "slug": "s"})
+	assert.Error(t, err)
+	_, err = p.Build(Params{"id": "7"})
+	assert.Error(t, err)
+	_, err = p.Build(Params{"id": "7", "slug": ".."})
+	assert.Error(t, err)
+
+	u, err = MustParsePattern("/static/{path...}").Build(Params{"path": "css/site.css"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/static/css/site.css", u.String())
+
+	for _, path := range []string{"a/../../etc/passwd", "./a", "a//b", "/a"} {
+		_, err = MustParsePattern("/files/{path...}").Build(Params{"path": path})
+		assert.Error(t, err, path)
+	}
+
+	u, err = MustParsePattern("/").Build(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/", u.String())
+}
@@ -0,0 +1,188 @@
+package urlx
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Query is an ordered list of query parameters. Unlike url.Values it keeps the original order and
+// duplicate keys, and Encode only re-encodes the parameters that changed.
+type Query struct {
+	params []param
+	raw    string
+	dirty  bool
+}
+
+type param struct {
+	key, value string
+	// raw is the parameter as it was parsed, "" once it changed.
+	raw string
+}
+
+func (p param) encode() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	return url.QueryEscape(p.key) + "=" + url.QueryEscape(p.value)
+}
+
+// ParseQuery parses a raw query such as "a=1&b=2&a=3". Parameters that fail to unescape are kept
+// verbatim under their raw key, so parsing never fails.
+func ParseQuery(raw string) *Query {
+	q := &Query{raw: raw}
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		q.params = append(q.params, param{key: key, value: value, raw: part})
+	}
+	return q
+}
+
+// QueryFromValues returns the query of values, sorted by key like url.Values.Encode.
+func QueryFromValues(values url.Values) *Query {
+	return ParseQuery(values.Encode())
+}
+
+// Len returns the number of parameters, duplicates included.
+func (q *Query) Len() int {
+	return len(q.params)
+}
+
+// Keys returns the distinct keys in order of first appearance.
+func (q *Query) Keys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, p := range q.params {
+		if !seen[p.key] {
+			seen[p.key] = true
+			keys = append(keys, p.key)
+		}
+	}
+	return keys
+}
+
+// Get returns the first value of key, or "".
+func (q *Query) Get(key string) string {
+	for _, p := range q.params {
+		if p.key == key {
+			return p.value
+		}
+	}
+	return ""
+}
+
+// GetAll returns all values of key in order.
+func (q *Query) GetAll(key string) []string {
+	var values []string
+	for _, p := range q.params {
+		if p.key == key {
+			values = append(values, p.value)
+		}
+	}
+	return values
+}
+
+// Has reports whether key is present, even with an empty value.
+func (q *Query) Has(key string) bool {
+	for _, p := range q.params {
+		if p.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a value to key.
+func (q *Query) Add(key, value string) *Query {
+	q.params = append(q.params, param{key: key, value: value})
+	q.dirty = true
+	return q
+}
+
+// Set replaces the values of key with value, at the position of its first occurrence or at the end.
+func (q *Query) Set(key, value string) *Query {
+	params := q.params[:0]
+	found := false
+	for _, p := range q.params {
+		if p.key != key {
+			params = append(params, p)
+			continue
+		}
+		if found {
+			continue
+		}
+		found = true
+		if p.value != value || p.raw == "" {
+			p = param{key: key, value: value}
+		}
+		params = append(params, p)
+	}
+	if !found {
+		params = append(params, param{key: key, value: value})
+	}
+	q.params = params
+	q.dirty = true
+	return q
+}
+
+// Del removes all values of key.
+func (q *Query) Del(key string) *Query {
+	params := q.params[:0]
+	for _, p := range q.params {
+		if p.key != key {
+			params = append(params, p)
+		}
+	}
+	q.params = params
+	q.dirty = true
+	return q
+}
+
+// Sort orders the parameters by key, values of the same key keep their order.
+func (q *Query) Sort() *Query {
+	sort.SliceStable(q.params, func(i, j int) bool { return q.params[i].key < q.params[j].key })
+	q.dirty = true
+	return q
+}
+
+// Values returns the parameters as url.Values, changes to it do not affect q.
+func (q *Query) Values() url.Values {
+	values := url.Values{}
+	for _, p := range q.params {
+		values[p.key] = append(values[p.key], p.value)
+	}
+	return values
+}
+
+// Clone returns a copy of q.
+func (q *Query) Clone() *Query {
+	c := *q
+	c.params = append([]param(nil), q.params...)
+	return &c
+}
+
+// Encode returns the raw query. An unchanged query is returned exactly as parsed, otherwise only
+// added and changed parameters are encoded and the others keep their original encoding.
+func (q *Query) Encode() string {
+	if !q.dirty {
+		return q.raw
+	}
+	parts := make([]string, len(q.params))
+	for i, p := range q.params {
+		parts[i] = p.encode()
+	}
+	return strings.Join(parts, "&")
+}
+
+func (q *Query) String() string {
+	return q.Encode()
+}
@@ -0,0 +1,68 @@
+package urlx
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	q := ParseQuery("b=2&a=1&a=x%2By&flag&c=%zz")
+	assert.Equal(t, 5, q.Len())
+	assert.Equal(t, []string{"b", "a", "flag", "c"}, q.Keys())
+	assert.Equal(t, []string{"1", "x+y"}, q.GetAll("a"))
+	assert.True(t, q.Has("flag"))
+	assert.Equal(t, "%zz", q.Get("c"))
+	assert.Equal(t, "b=2&a=1&a=x%2By&flag&c=%zz", q.Encode())
+
+	q.Set("a", "new value").Add("d", "&").Del("b")
+	assert.Equal(t, "a=new+value&flag&c=%zz&d=%26", q.Encode())
+	assert.Equal(t, url.Values{"a": {"new value"}, "flag": {""}, "c": {"%zz"}, "d": {"&"}}, q.Values())
+
+	q.Sort()
+	assert.Equal(t, "a=new+value&c=%zz&d=%26&flag", q.Encode())
+}
+
+func TestURL_Query(t *testing.T) {
+	u, err := FromString("https://example.com/search?q=go%20lang&page=2&tag=a&tag=b")
+	assert.NoError(t, err)
+
+	// no panic without calling Query first
+	u.SetQueryValue("page", "3").UnsetQueryValue("missing").AddQueryValue("tag", "c")
+	assert.Equal(t, "3", u.GetQuery("page"))
+	assert.Equal(t, []string{"a", "b", "c"}, u.QueryParams().GetAll("tag"))
+	assert.Equal(t, "https://example.com/search?q=go%20lang&page=3&tag=a&tag=b&tag=c", u.String())
+
+	u.SetQuery(url.Values{"z": {"1"}, "y": {"2"}})
+	assert.Equal(t, "2", u.GetQuery("y"))
+	assert.Equal(t, "y=2&z=1", u.RawQuery())
+
+	u.SetRawQuery("x=1")
+	assert.False(t, u.QueryParams().Has("y"))
+	assert.Equal(t, "https://example.com/search?x=1", u.String())
+}
+
+func TestURL_QueryValues(t *testing.T) {
+	u, err := FromString("http://x/?z=1")
+	assert.NoError(t, err)
+
+	// the map is a copy, edits to it never reach the URL
+	values := u.Query()
+	assert.Equal(t, url.Values{"z": {"1"}}, values)
+	u.SetQueryValue("a", "1")
+	values.Set("b", "2")
+	assert.Equal(t, "http://x/?z=1&a=1", u.String())
+
+	again := u.Query()
+	assert.Equal(t, url.Values{"z": {"1"}, "a": {"1"}}, again)
+	again.Set("b", "2")
+	values.Del("z")
+	u.AddQueryValue("c", "3")
+	assert.Equal(t, "http://x/?z=1&a=1&c=3", u.String())
+
+	// changes go through SetQuery
+	again.Set("c", "3")
+	u.SetQuery(again)
+	assert.Equal(t, "http://x/?a=1&b=2&c=3&z=1", u.String())
+}
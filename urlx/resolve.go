@@ -0,0 +1,158 @@
+package urlx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reference is a URI reference split into its five components, RFC 3986 appendix B. The has flags
+// tell an empty component from an undefined one, "?" from no query at all.
+type reference struct {
+	scheme, authority, path, query, fragment       string
+	hasScheme, hasAuthority, hasQuery, hasFragment bool
+}
+
+var referenceRegexp = regexp.MustCompile(`^(([^:/?#]+):)?(//([^/?#]*))?([^?#]*)(\?([^#]*))?(#(.*))?$`)
+
+func parseReference(s string) reference {
+	m := referenceRegexp.FindStringSubmatch(s)
+	return reference{
+		scheme: m[2], hasScheme: m[1] != "",
+		authority: m[4], hasAuthority: m[3] != "",
+		path:  m[5],
+		query: m[7], hasQuery: m[6] != "",
+		fragment: m[9], hasFragment: m[8] != "",
+	}
+}
+
+// String recomposes the reference, RFC 3986 section 5.3.
+func (r reference) String() string {
+	var b strings.Builder
+	if r.hasScheme {
+		b.WriteString(r.scheme + ":")
+	}
+	if r.hasAuthority {
+		b.WriteString("//" + r.authority)
+	}
+	b.WriteString(r.path)
+	if r.hasQuery {
+		b.WriteString("?" + r.query)
+	}
+	if r.hasFragment {
+		b.WriteString("#" + r.fragment)
+	}
+	return b.String()
+}
+
+// Resolve resolves the reference ref, e.g. "../img/logo.png", against the URL as base,
+// following RFC 3986 section 5.2 strictly.
+func (u *URL) Resolve(ref string) (*URL, error) {
+	base, r := parseReference(u.String()), parseReference(ref)
+
+	var t reference
+	switch {
+	case r.hasScheme:
+		t = r
+		t.path = removeDotSegments(r.path)
+	case r.hasAuthority:
+		t = r
+		t.scheme, t.hasScheme = base.scheme, base.hasScheme
+		t.path = removeDotSegments(r.path)
+	default:
+		t = r
+		t.scheme, t.hasScheme = base.scheme, base.hasScheme
+		t.authority, t.hasAuthority = base.authority, base.hasAuthority
+		switch {
+		case r.path == "":
+			t.path = base.path
+			if !r.hasQuery {
+				t.query, t.hasQuery = base.query, base.hasQuery
+			}
+		case strings.HasPrefix(r.path, "/"):
+			t.path = removeDotSegments(r.path)
+		default:
+			t.path = removeDotSegments(mergePaths(base, r.path))
+		}
+	}
+	return FromString(t.String())
+}
+
+// mergePaths appends a relative path to the directory of the base path, RFC 3986 section 5.2.3.
+func mergePaths(base reference, path string) string {
+	if base.hasAuthority && base.path == "" {
+		return "/" + path
+	}
+	return base.path[:strings.LastIndexByte(base.path, '/')+1] + path
+}
+
+// RelativeTo returns the shortest reference that resolves against base to the URL, using "../"
+// segments where that is shorter than an absolute path. Other schemes give the absolute URL and
+// other hosts a network-path reference such as "//cdn.example.com/app.js", as does an empty target
+// path since no relative path resolves to one.
+func (u *URL) RelativeTo(base *URL) string {
+	b, t := parseReference(base.String()), parseReference(u.String())
+	rawPath := t.path
+	t.path = removeDotSegments(t.path)
+
+	if !strings.EqualFold(b.scheme, t.scheme) || b.hasScheme != t.hasScheme {
+		return t.String()
+	}
+	t.scheme, t.hasScheme = "", false
+	if b.authority != t.authority || b.hasAuthority != t.hasAuthority {
+		return t.String()
+	}
+	if t.path == "" && t.hasAuthority {
+		// no relative path resolves to an empty path, keep the authority unless the base path is
+		// empty too and the query is kept or replaced
+		if b.path != "" || !t.hasQuery && b.hasQuery {
+			return t.String()
+		}
+		if t.hasQuery == b.hasQuery && t.query == b.query {
+			t.hasQuery, t.query = false, ""
+		}
+		t.authority, t.hasAuthority = "", false
+		return t.String()
+	}
+	t.authority, t.hasAuthority = "", false
+
+	// an empty path keeps the base path as it is, other paths are merged with its directory and
+	// then cleaned, so compare against the same
+	switch {
+	case rawPath == b.path && t.hasQuery == b.hasQuery && t.query == b.query:
+		// "" keeps the whole base but its fragment
+		t.path, t.hasQuery, t.query = "", false, ""
+	case rawPath == b.path && t.hasQuery:
+		t.path = ""
+	default:
+		t.path = relativePath(removeDotSegments(mergePaths(b, "")), t.path)
+	}
+	return t.String()
+}
+
+// relativePath returns the shortest relative or absolute path that merges with base to target.
+func relativePath(base, target string) string {
+	if !strings.HasPrefix(target, "/") || !strings.HasPrefix(base, "/") {
+		return target
+	}
+	dir := strings.Split(base[:strings.LastIndexByte(base, '/')], "/")
+	segments := strings.Split(target, "/")
+
+	common := 0
+	for common < len(dir) && common < len(segments)-1 && dir[common] == segments[common] {
+		common++
+	}
+	rel := strings.Repeat("../", len(dir)-common) + strings.Join(segments[common:], "/")
+
+	first, _, _ := strings.Cut(rel, "/")
+	switch {
+	case rel == "":
+		rel = "./"
+	case strings.HasPrefix(rel, "/"), strings.Contains(first, ":"):
+		// an empty first segment would read as an absolute path and a colon as a scheme
+		rel = "./" + rel
+	}
+	if len(rel) > len(target) && !strings.HasPrefix(target, "//") {
+		return target
+	}
+	return rel
+}
@@ -0,0 +1,110 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL_Resolve(t *testing.T) {
+	base, err := FromString("http://a/b/c/d;p?q")
+	assert.NoError(t, err)
+
+	// RFC 3986 section 5.4
+	tests := map[string]string{
+		"g:h":     "g:h",
+		"g":       "http://a/b/c/g",
+		"./g":     "http://a/b/c/g",
+		"g/":      "http://a/b/c/g/",
+		"/g":      "http://a/g",
+		"//g":     "http://g",
+		"?y":      "http://a/b/c/d;p?y",
+		"g?y":     "http://a/b/c/g?y",
+		"#s":      "http://a/b/c/d;p?q#s",
+		"g#s":     "http://a/b/c/g#s",
+		"g?y#s":   "http://a/b/c/g?y#s",
+		";x":      "http://a/b/c/;x",
+		"g;x":     "http://a/b/c/g;x",
+		"g;x?y#s": "http://a/b/c/g;x?y#s",
+		"":        "http://a/b/c/d;p?q",
+		".":       "http://a/b/c/",
+		"./":      "http://a/b/c/",
+		"..":      "http://a/b/",
+		"../":     "http://a/b/",
+		"../g":    "http://a/b/g",
+		"../..":   "http://a/",
+		"../../":  "http://a/",
+		"../../g": "http://a/g",
+
+		"../../../g":    "http://a/g",
+		"../../../../g": "http://a/g",
+		"/./g":          "http://a/g",
+		"/../g":         "http://a/g",
+		"g.":            "http://a/b/c/g.",
+		".g":            "http://a/b/c/.g",
+		"g..":           "http://a/b/c/g..",
+		"..g":           "http://a/b/c/..g",
+		"./../g":        "http://a/b/g",
+		"./g/.":         "http://a/b/c/g/",
+		"g/./h":         "http://a/b/c/g/h",
+		"g/../h":        "http://a/b/c/h",
+		"g;x=1/./y":     "http://a/b/c/g;x=1/y",
+		"g;x=1/../y":    "http://a/b/c/y",
+		"g?y/./x":       "http://a/b/c/g?y/./x",
+		"g?y/../x":      "http://a/b/c/g?y/../x",
+		"g#s/./x":       "http://a/b/c/g#s/./x",
+		"g#s/../x":      "http://a/b/c/g#s/../x",
+		"http:g":        "http:g",
+	}
+	for ref, want := range tests {
+		got, err := base.Resolve(ref)
+		assert.NoError(t, err, ref)
+		assert.Equal(t, want, got.String(), ref)
+	}
+}
+
+func TestURL_RelativeTo(t *testing.T) {
+	tests := []struct {
+		base, target, want string
+	}{
+		{"http://a/b/c/d;p?q", "http://a/b/c/g", "g"},
+		{"http://a/b/c/d;p?q", "http://a/b/g", "../g"},
+		{"http://a/b/c/d;p?q", "http://a/g", "/g"},
+		{"http://a/b/c/d;p?q", "http://a/b/c/", "./"},
+		{"http://a/b/c/d;p?q", "http://a/b/c/d;p?y", "?y"},
+		{"http://a/b/c/d;p?q", "http://a/b/c/d;p", "d;p"},
+		{"http://a/b/c/d;p?q", "http://a/b/c/d;p?q#s", "#s"},
+		{"http://a/b/c/d;p?q", "http://a/b/c/d;p?q", ""},
+		{"http://a/b/c/d;p?q", "http://a/b/c/x:y", "./x:y"},
+		{"http://a/b/c/d;p?q", "http://a/b/c//x", ".//x"},
+		{"http://a/b/c/d;p?q", "https://a/b/c/g", "https://a/b/c/g"},
+		{"http://a/b/c/d;p?q", "http://cdn/app.js", "//cdn/app.js"},
+		{"http://a/docs/guide/intro.html", "http://a/docs/api/index.html#top", "../api/index.html#top"},
+		{"http://a", "http://a/x/y", "x/y"},
+		{"http://a/b/c/d;p?q", "http://a", "//a"},
+		{"http://a/b/c/d;p?q", "http://a?y#s", "//a?y#s"},
+		{"http://a?q", "http://a", "//a"},
+		{"http://a?q", "http://a?y", "?y"},
+		{"http://a?q", "http://a?q#s", "#s"},
+		{"http://a", "http://a#s", "#s"},
+		{"http://h/x:y/x:y/../..?q", "http://h/a/c;p?q", "/a/c;p?q"},
+		{"http://h/x:y/x:y/../..?q", "http://h/x:y/b", "b"},
+		{"http://h?q#f", "http://h/?#f", "/?#f"},
+		{"http://h/.", "http://h/.", ""},
+		{"http://h/.", "http://h/", "/"},
+	}
+	for _, tt := range tests {
+		base, err := FromString(tt.base)
+		assert.NoError(t, err)
+		target, err := FromString(tt.target)
+		assert.NoError(t, err)
+
+		rel := target.RelativeTo(base)
+		assert.Equal(t, tt.want, rel, tt.target)
+
+		// resolving the relative form gives back the original
+		resolved, err := base.Resolve(rel)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.target, resolved.String(), rel)
+	}
+}
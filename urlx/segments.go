@@ -0,0 +1,151 @@
+package urlx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Segments returns the unescaped path segments, "/a%2Fb/c/" gives ["a/b", "c"]. A trailing slash
+// does not add an empty segment, see HasTrailingSlash.
+func (u *URL) Segments() []string {
+	path := strings.TrimSuffix(strings.TrimPrefix(u.u.EscapedPath(), "/"), "/")
+	if path == "" {
+		return nil
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if s, err := url.PathUnescape(segment); err == nil {
+			segments[i] = s
+		}
+	}
+	return segments
+}
+
+// ErrDotSegment is returned for "." and ".." segments, they cannot be represented as plain names:
+// "%2E" is the same as "." (RFC 3986 section 6.2.2.2) and is removed by normalization.
+var ErrDotSegment = errors.New("dot segment")
+
+// SetSegments replaces the path with the segments, each escaped on its own so "/" and "?" stay
+// part of the segment. The trailing slash is kept.
+func (u *URL) SetSegments(segments ...string) error {
+	if err := checkSegments(segments); err != nil {
+		return err
+	}
+	u.setSegments(segments, u.HasTrailingSlash())
+	return nil
+}
+
+// AppendSegments appends escaped segments to the path, the result has no trailing slash.
+func (u *URL) AppendSegments(segments ...string) error {
+	if err := checkSegments(segments); err != nil {
+		return err
+	}
+	u.setSegments(append(u.Segments(), segments...), false)
+	return nil
+}
+
+// SetSegment replaces the segment at i, negative indexes count from the end. An index out of range
+// leaves the path alone.
+func (u *URL) SetSegment(i int, segment string) error {
+	if err := checkSegments([]string{segment}); err != nil {
+		return err
+	}
+	segments := u.Segments()
+	if i < 0 {
+		i += len(segments)
+	}
+	if i < 0 || i >= len(segments) {
+		return nil
+	}
+	segments[i] = segment
+	u.setSegments(segments, u.HasTrailingSlash())
+	return nil
+}
+
+func checkSegments(segments []string) error {
+	for _, segment := range segments {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("segment %q: %w", segment, ErrDotSegment)
+		}
+	}
+	return nil
+}
+
+// PopSegment removes the last segment and returns it, or "" when the path has none.
+func (u *URL) PopSegment() string {
+	segments := u.Segments()
+	if len(segments) == 0 {
+		return ""
+	}
+	u.setSegments(segments[:len(segments)-1], u.HasTrailingSlash())
+	return segments[len(segments)-1]
+}
+
+// JoinPath joins the elements to the path like path.Join, slashes in the elements separate
+// segments and "." and ".." are resolved. The result ends with a slash when the last element does.
+func (u *URL) JoinPath(elem ...string) *URL {
+	if len(elem) == 0 {
+		return u
+	}
+
+	segments := u.Segments()
+	for _, e := range elem {
+		for _, segment := range strings.Split(e, "/") {
+			switch segment {
+			case "", ".":
+			case "..":
+				if len(segments) > 0 {
+					segments = segments[:len(segments)-1]
+				}
+			default:
+				segments = append(segments, segment)
+			}
+		}
+	}
+	return u.setSegments(segments, strings.HasSuffix(elem[len(elem)-1], "/"))
+}
+
+// HasTrailingSlash reports whether the path ends with a slash.
+func (u *URL) HasTrailingSlash() bool {
+	return strings.HasSuffix(u.u.EscapedPath(), "/")
+}
+
+// SetTrailingSlash adds or removes the trailing slash of the path.
+func (u *URL) SetTrailingSlash(trailing bool) *URL {
+	return u.setSegments(u.Segments(), trailing)
+}
+
+// setSegments escapes and joins the segments, keeping Path and RawPath in sync. The path stays
+// relative if it was and has no host.
+func (u *URL) setSegments(segments []string, trailing bool) *URL {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = escapeSegment(segment)
+	}
+
+	path := strings.Join(escaped, "/")
+	if trailing && path != "" {
+		path += "/"
+	}
+	old := u.u.EscapedPath()
+	if (u.u.Host != "" || old == "" || strings.HasPrefix(old, "/")) && (path != "" || trailing) {
+		path = "/" + path
+	}
+	u.setEscapedPath(path)
+	return u
+}
+
+// escapeSegment escapes a single segment. "." and ".." only come from paths that already had them
+// percent-encoded and are written back that way.
+func escapeSegment(segment string) string {
+	switch segment {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(segment)
+}
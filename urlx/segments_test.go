@@ -0,0 +1,57 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL_Segments(t *testing.T) {
+	u, err := FromString("https://api.example.com/v1/files/a%2Fb/?x=1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1", "files", "a/b"}, u.Segments())
+	assert.True(t, u.HasTrailingSlash())
+
+	assert.NoError(t, u.SetSegment(-1, "report 2024?.pdf"))
+	assert.Equal(t, "https://api.example.com/v1/files/report%202024%3F.pdf/?x=1", u.String())
+	assert.Equal(t, "/v1/files/report 2024?.pdf/", u.Path())
+
+	assert.ErrorIs(t, u.SetSegment(0, "."), ErrDotSegment)
+	assert.ErrorIs(t, u.AppendSegments("a", ".."), ErrDotSegment)
+	assert.ErrorIs(t, u.SetSegments(".."), ErrDotSegment)
+	assert.Equal(t, "https://api.example.com/v1/files/report%202024%3F.pdf/?x=1", u.String())
+
+	assert.NoError(t, u.SetTrailingSlash(false).AppendSegments("ünï/cödé", "..."))
+	assert.Equal(t, "https://api.example.com/v1/files/report%202024%3F.pdf/%C3%BCn%C3%AF%2Fc%C3%B6d%C3%A9/...?x=1", u.String())
+	assert.Equal(t, []string{"v1", "files", "report 2024?.pdf", "ünï/cödé", "..."}, u.Segments())
+
+	assert.Equal(t, "...", u.PopSegment())
+	assert.Equal(t, "ünï/cödé", u.PopSegment())
+	assert.Equal(t, "https://api.example.com/v1/files/report%202024%3F.pdf?x=1", u.String())
+
+	assert.NoError(t, u.SetSegment(9, "ignored"))
+	assert.Len(t, u.Segments(), 3)
+}
+
+func TestURL_JoinPath(t *testing.T) {
+	u, err := FromString("https://example.com/api/")
+	assert.NoError(t, err)
+
+	u.JoinPath("users", "42/../7", "a b/")
+	assert.Equal(t, "https://example.com/api/users/7/a%20b/", u.String())
+
+	u.JoinPath("..", "c")
+	assert.Equal(t, "https://example.com/api/users/7/c", u.String())
+
+	rel, err := FromString("docs/guide")
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/guide/intro", rel.JoinPath("intro").String())
+
+	empty, err := FromString("https://example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, empty.AppendSegments("x"))
+	assert.Equal(t, "https://example.com/x", empty.String())
+	assert.Equal(t, "x", empty.PopSegment())
+	assert.Equal(t, "", empty.PopSegment())
+	assert.Equal(t, "https://example.com", empty.String())
+}
@@ -0,0 +1,330 @@
+package urlx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Template is a URI Template (RFC 6570) such as "/repos/{owner}/{repo}/issues{?state,labels*}",
+// all four levels are supported.
+type Template struct {
+	raw   string
+	parts []templatePart
+}
+
+// templatePart is a literal, or an expression when vars is set.
+type templatePart struct {
+	literal string
+	op      *templateOp
+	vars    []varSpec
+}
+
+type varSpec struct {
+	name    string
+	explode bool
+	prefix  int
+}
+
+// templateOp describes an operator, RFC 6570 appendix A.
+type templateOp struct {
+	first, sep string
+	named      bool
+	ifEmpty    string
+	reserved   bool
+}
+
+var templateOps = map[byte]*templateOp{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+	'#': {first: "#", sep: ",", reserved: true},
+}
+
+// ParseTemplate parses a URI Template.
+func ParseTemplate(s string) (*Template, error) {
+	t := &Template{raw: s}
+	for s != "" {
+		open := strings.IndexAny(s, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: s})
+			break
+		}
+		if s[open] == '}' {
+			return nil, fmt.Errorf("template %q: unexpected '}'", t.raw)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: s[:open]})
+		}
+
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("template %q: unclosed '{'", t.raw)
+		}
+		part, err := parseExpression(s[open+1 : open+end])
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", t.raw, err)
+		}
+		t.parts = append(t.parts, part)
+		s = s[open+end+1:]
+	}
+	return t, nil
+}
+
+// MustParseTemplate is ParseTemplate panicking on errors, for templates in variables.
+func MustParseTemplate(s string) *Template {
+	t, err := ParseTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseExpression(expr string) (templatePart, error) {
+	var opChar byte
+	if expr != "" && strings.IndexByte("+#./;?&=,!@|", expr[0]) >= 0 {
+		opChar, expr = expr[0], expr[1:]
+	}
+	op, ok := templateOps[opChar]
+	if !ok {
+		return templatePart{}, fmt.Errorf("reserved operator %q", opChar)
+	}
+
+	part := templatePart{op: op}
+	for _, spec := range strings.Split(expr, ",") {
+		v := varSpec{name: spec}
+		if name, ok := strings.CutSuffix(spec, "*"); ok {
+			v.name, v.explode = name, true
+		} else if name, prefix, ok := strings.Cut(spec, ":"); ok {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n < 1 || n > 9999 || prefix[0] == '+' {
+				return templatePart{}, fmt.Errorf("invalid prefix %q", spec)
+			}
+			v.name, v.prefix = name, n
+		}
+		if !validVarName(v.name) {
+			return templatePart{}, fmt.Errorf("invalid variable name %q", v.name)
+		}
+		part.vars = append(part.vars, v)
+	}
+	return part, nil
+}
+
+// validVarName reports whether name matches varname, RFC 6570 section 2.3.
+func validVarName(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' || strings.Contains(name, "..") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_', c == '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the template as parsed.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Variables returns the names of the variables in order of appearance, without duplicates.
+func (t *Template) Variables() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, part := range t.parts {
+		for _, v := range part.vars {
+			if !seen[v.name] {
+				seen[v.name] = true
+				names = append(names, v.name)
+			}
+		}
+	}
+	return names
+}
+
+// Expand expands the template and parses the result. Values are strings, or anything else printed
+// with fmt, lists are []string and associative arrays [][2]string to keep their order or
+// map[string]string expanded in key order. Missing and nil values, empty lists and empty maps
+// are undefined and left out.
+func (t *Template) Expand(vars map[string]any) (*URL, error) {
+	s, err := t.ExpandString(vars)
+	if err != nil {
+		return nil, err
+	}
+	return FromString(s)
+}
+
+// ExpandString is Expand returning the expanded string.
+func (t *Template) ExpandString(vars map[string]any) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.op == nil {
+			b.WriteString(encodeTemplate(part.literal, true))
+			continue
+		}
+		if err := part.expand(&b, vars); err != nil {
+			return "", fmt.Errorf("template %q: %w", t.raw, err)
+		}
+	}
+	return b.String(), nil
+}
+
+var errPrefixComposite = errors.New("prefix modifier on a list or associative array")
+
+func (part templatePart) expand(b *strings.Builder, vars map[string]any) error {
+	op := part.op
+	first := true
+	for _, v := range part.vars {
+		value, list, pairs := templateValue(vars[v.name])
+		if value == nil && list == nil && pairs == nil {
+			continue
+		}
+		if v.prefix > 0 && value == nil {
+			return fmt.Errorf("%s: %w", v.name, errPrefixComposite)
+		}
+
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+
+		switch {
+		case value != nil:
+			if op.named {
+				b.WriteString(v.name)
+				if *value == "" {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteByte('=')
+			}
+			s := *value
+			if v.prefix > 0 {
+				s = truncateRunes(s, v.prefix)
+			}
+			b.WriteString(encodeTemplate(s, op.reserved))
+		case !v.explode:
+			if op.named {
+				b.WriteString(v.name + "=")
+			}
+			items := list
+			for _, pair := range pairs {
+				items = append(items, pair[0], pair[1])
+			}
+			for i, item := range items {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(encodeTemplate(item, op.reserved))
+			}
+		case list != nil:
+			for i, item := range list {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				part.writeNamed(b, v.name, item)
+			}
+		default:
+			for i, pair := range pairs {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				if op.named {
+					part.writeNamed(b, encodeTemplate(pair[0], op.reserved), pair[1])
+				} else {
+					b.WriteString(encodeTemplate(pair[0], op.reserved) + "=" + encodeTemplate(pair[1], op.reserved))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeNamed writes an exploded item, "name=value" for named operators.
+func (part templatePart) writeNamed(b *strings.Builder, name, value string) {
+	if part.op.named {
+		b.WriteString(name)
+		if value == "" {
+			b.WriteString(part.op.ifEmpty)
+			return
+		}
+		b.WriteByte('=')
+	}
+	b.WriteString(encodeTemplate(value, part.op.reserved))
+}
+
+// templateValue classifies a variable, undefined values return all nil.
+func templateValue(v any) (value *string, list []string, pairs [][2]string) {
+	switch v := v.(type) {
+	case nil:
+	case string:
+		value = &v
+	case []string:
+		if len(v) > 0 {
+			list = v
+		}
+	case [][2]string:
+		if len(v) > 0 {
+			pairs = v
+		}
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			pairs = append(pairs, [2]string{k, v[k]})
+		}
+	default:
+		s := fmt.Sprint(v)
+		value = &s
+	}
+	return value, list, pairs
+}
+
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// encodeTemplate percent-encodes everything but unreserved characters, and with reserved also
+// reserved characters and existing percent-encodings.
+func encodeTemplate(s string, reserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c < utf8.RuneSelf && isUnreserved(c):
+			b.WriteByte(c)
+		case reserved && c < utf8.RuneSelf && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
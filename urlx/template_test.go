@@ -0,0 +1,171 @@
+package urlx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6570Vars are the variables of the examples in RFC 6570 sections 1.2 and 3.2.
+var rfc6570Vars = map[string]any{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       [][2]string{{"semi", ";"}, {"dot", "."}, {"comma", ","}},
+	"v":          "6",
+	"x":          1024,
+	"y":          "768",
+	"empty":      "",
+	"empty_keys": [][2]string{},
+	"undef":      nil,
+}
+
+func TestTemplate_RFC6570(t *testing.T) {
+	tests := map[string]string{
+		// level 1
+		"{var}":   "value",
+		"{hello}": "Hello%20World%21",
+		// level 2
+		"{+var}":           "value",
+		"{+hello}":         "Hello%20World!",
+		"{+path}/here":     "/foo/bar/here",
+		"here?ref={+path}": "here?ref=/foo/bar",
+		"X{#var}":          "X#value",
+		"X{#hello}":        "X#Hello%20World!",
+		// level 3
+		"map?{x,y}":        "map?1024,768",
+		"{x,hello,y}":      "1024,Hello%20World%21,768",
+		"{+x,hello,y}":     "1024,Hello%20World!,768",
+		"{+path,x}/here":   "/foo/bar,1024/here",
+		"{#x,hello,y}":     "#1024,Hello%20World!,768",
+		"{#path,x}/here":   "#/foo/bar,1024/here",
+		"X{.var}":          "X.value",
+		"X{.x,y}":          "X.1024.768",
+		"{/var}":           "/value",
+		"{/var,x}/here":    "/value/1024/here",
+		"{;x,y}":           ";x=1024;y=768",
+		"{;x,y,empty}":     ";x=1024;y=768;empty",
+		"{?x,y}":           "?x=1024&y=768",
+		"{?x,y,empty}":     "?x=1024&y=768&empty=",
+		"?fixed=yes{&x}":   "?fixed=yes&x=1024",
+		"{&x,y,empty}":     "&x=1024&y=768&empty=",
+		"{half}":           "50%25",
+		"O{empty}X":        "OX",
+		"O{undef}X":        "OX",
+		"?{x,empty}":       "?1024,",
+		"?{x,undef}":       "?1024",
+		"?{undef,y}":       "?768",
+		"{base}index":      "http%3A%2F%2Fexample.com%2Fhome%2Findex",
+		"{+base}index":     "http://example.com/home/index",
+		"O{+empty}X":       "OX",
+		"up{+path}{var}/x": "up/foo/barvalue/x",
+		"foo{#empty}":      "foo#",
+		"foo{#undef}":      "foo",
+		"{.who,who}":       ".fred.fred",
+		"{.half,who}":      ".50%25.fred",
+		"www{.dom*}":       "www.example.com",
+		"X{.empty}":        "X.",
+		"X{.undef}":        "X",
+		"{/who,dub}":       "/fred/me%2Ftoo",
+		"{/var,empty}":     "/value/",
+		"{/var,undef}":     "/value",
+		"{;half}":          ";half=50%25",
+		"{;v,empty,who}":   ";v=6;empty;who=fred",
+		"{;v,bar,who}":     ";v=6;who=fred",
+		"{;x,y,undef}":     ";x=1024;y=768",
+		"{?x,y,undef}":     "?x=1024&y=768",
+		"{count}":          "one,two,three",
+		"{count*}":         "one,two,three",
+		"{/count}":         "/one,two,three",
+		"{/count*}":        "/one/two/three",
+		"{;count}":         ";count=one,two,three",
+		"{;count*}":        ";count=one;count=two;count=three",
+		"{?count}":         "?count=one,two,three",
+		"{?count*}":        "?count=one&count=two&count=three",
+		"{&count*}":        "&count=one&count=two&count=three",
+		// level 4
+		"{var:3}":             "val",
+		"{var:30}":            "value",
+		"{list}":              "red,green,blue",
+		"{list*}":             "red,green,blue",
+		"{keys}":              "semi,%3B,dot,.,comma,%2C",
+		"{keys*}":             "semi=%3B,dot=.,comma=%2C",
+		"{+path:6}/here":      "/foo/b/here",
+		"{+list}":             "red,green,blue",
+		"{+list*}":            "red,green,blue",
+		"{+keys}":             "semi,;,dot,.,comma,,",
+		"{+keys*}":            "semi=;,dot=.,comma=,",
+		"{#path:6}/here":      "#/foo/b/here",
+		"{#list}":             "#red,green,blue",
+		"{#list*}":            "#red,green,blue",
+		"{#keys}":             "#semi,;,dot,.,comma,,",
+		"{#keys*}":            "#semi=;,dot=.,comma=,",
+		"X{.var:3}":           "X.val",
+		"X{.list}":            "X.red,green,blue",
+		"X{.list*}":           "X.red.green.blue",
+		"X{.keys}":            "X.semi,%3B,dot,.,comma,%2C",
+		"X{.keys*}":           "X.semi=%3B.dot=..comma=%2C",
+		"{/var:1,var}":        "/v/value",
+		"{/list}":             "/red,green,blue",
+		"{/list*}":            "/red/green/blue",
+		"{/list*,path:4}":     "/red/green/blue/%2Ffoo",
+		"{/keys}":             "/semi,%3B,dot,.,comma,%2C",
+		"{/keys*}":            "/semi=%3B/dot=./comma=%2C",
+		"{;hello:5}":          ";hello=Hello",
+		"{;list}":             ";list=red,green,blue",
+		"{;list*}":            ";list=red;list=green;list=blue",
+		"{;keys}":             ";keys=semi,%3B,dot,.,comma,%2C",
+		"{;keys*}":            ";semi=%3B;dot=.;comma=%2C",
+		"{?var:3}":            "?var=val",
+		"{?list}":             "?list=red,green,blue",
+		"{?list*}":            "?list=red&list=green&list=blue",
+		"{?keys}":             "?keys=semi,%3B,dot,.,comma,%2C",
+		"{?keys*}":            "?semi=%3B&dot=.&comma=%2C",
+		"{&var:3}":            "&var=val",
+		"{&list}":             "&list=red,green,blue",
+		"{&list*}":            "&list=red&list=green&list=blue",
+		"{&keys}":             "&keys=semi,%3B,dot,.,comma,%2C",
+		"{&keys*}":            "&semi=%3B&dot=.&comma=%2C",
+		"{?empty_keys*}":      "",
+		"{?undef,empty_keys}": "",
+	}
+	for tpl, want := range tests {
+		tmpl, err := ParseTemplate(tpl)
+		if !assert.NoError(t, err, tpl) {
+			continue
+		}
+		got, err := tmpl.ExpandString(rfc6570Vars)
+		assert.NoError(t, err, tpl)
+		assert.Equal(t, want, got, tpl)
+	}
+}
+
+func TestTemplate_Expand(t *testing.T) {
+	tmpl := MustParseTemplate("https://api.github.com/repos/{owner}/{repo}/issues{?state,labels*}")
+	assert.Equal(t, []string{"owner", "repo", "state", "labels"}, tmpl.Variables())
+
+	u, err := tmpl.Expand(map[string]any{
+		"owner":  "rogeecn",
+		"repo":   "tool box",
+		"state":  "open",
+		"labels": []string{"bug", "help wanted"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/repos/rogeecn/tool box/issues", u.Path())
+	assert.Equal(t, []string{"bug", "help wanted"}, u.QueryParams().GetAll("labels"))
+	assert.Equal(t, "https://api.github.com/repos/rogeecn/tool%20box/issues?state=open&labels=bug&labels=help%20wanted", u.String())
+
+	for _, bad := range []string{"{", "}", "{var", "{=var}", "{va r}", "{var:0}", "{var:10000}", "{.var.}"} {
+		_, err := ParseTemplate(bad)
+		assert.Error(t, err, bad)
+	}
+	_, err = MustParseTemplate("{keys:1}").ExpandString(rfc6570Vars)
+	assert.ErrorIs(t, err, errPrefixComposite)
+}
@@ -3,8 +3,9 @@ package urlx
 import "net/url"
 
 type URL struct {
-	u     *url.URL
-	query url.Values
+	u *url.URL
+	// query is parsed from u.RawQuery on first use and is the source of truth from then on.
+	query *Query
 }
 
 func New(u *url.URL) *URL {
@@ -21,29 +22,38 @@ func FromString(s string) (*URL, error) {
 }
 
 func (u *URL) String() string {
-	if u.query != nil {
-		u.u.RawQuery = u.query.Encode()
-	}
-	return u.u.String()
+	c := *u.u
+	c.RawQuery = u.RawQuery()
+	return c.String()
 }
 
 func (u *URL) Path() string {
 	return u.u.Path
 }
 
+// Query returns a copy of the query as url.Values, changes to the map do not affect the URL. Edit
+// the query through QueryParams, the setters or SetQuery.
 func (u *URL) Query() url.Values {
+	return u.QueryParams().Values()
+}
+
+// QueryParams returns the ordered query of the URL, changes to it show up in String.
+func (u *URL) QueryParams() *Query {
 	if u.query == nil {
-		u.query = u.u.Query()
+		u.query = ParseQuery(u.u.RawQuery)
 	}
 	return u.query
 }
 
 func (u *URL) RawQuery() string {
+	if u.query != nil {
+		return u.query.Encode()
+	}
 	return u.u.RawQuery
 }
 
 func (u *URL) GetQuery(key string) string {
-	return u.u.Query().Get(key)
+	return u.QueryParams().Get(key)
 }
 
 func (u *URL) Fragment() string {
@@ -79,24 +89,31 @@ func (u *URL) SetPath(path string) *URL {
 
 // SetQuery
 func (u *URL) SetQuery(query url.Values) *URL {
-	u.u.RawQuery = query.Encode()
+	u.query = QueryFromValues(query)
 	return u
 }
 
 // SetRawQuery
 func (u *URL) SetRawQuery(rawQuery string) *URL {
 	u.u.RawQuery = rawQuery
+	u.query = nil
 	return u
 }
 
 // SetQueryValue
 func (u *URL) SetQueryValue(key, value string) *URL {
-	u.query.Set(key, value)
+	u.QueryParams().Set(key, value)
+	return u
+}
+
+// AddQueryValue
+func (u *URL) AddQueryValue(key, value string) *URL {
+	u.QueryParams().Add(key, value)
 	return u
 }
 
 func (u *URL) UnsetQueryValue(key string) *URL {
-	u.query.Del(key)
+	u.QueryParams().Del(key)
 	return u
 }
 